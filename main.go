@@ -1,7 +1,560 @@
 package main
 
-import "fmt"
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
-func main()  {
-	fmt.Println("go-util")
+	"github.com/ahmetask/go-util/stream"
+)
+
+const usage = `usage: go-util [flags] [file ...]
+
+reads json array, ndjson or csv records from files or stdin and writes the result.
+functions run in order query, filter, group-by, map, sort and limit.
+
+  go-util --filter 'age > 30' --map '{name, age}' --sort name --limit 10 people.json
+  cat people.csv | go-util --group-by city --map '{city, count}' --sort -count --out table
+
+flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// exit code is 2 for invalid flags and expressions, 1 for read and evaluation errors
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts options
+	flags := flag.NewFlagSet("go-util", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&opts.in, "in", "", "input format json, ndjson or csv. default is found by file extension or first character")
+	flags.StringVar(&opts.out, "out", "json", "output format json, ndjson, csv or table")
+	flags.StringVar(&opts.query, "query", "", "SQL like query over records, `SELECT name FROM . WHERE age > 30`")
+	flags.StringVar(&opts.filter, "filter", "", "keeps records matching expression like `age > 30`")
+	flags.StringVar(&opts.groupBy, "group-by", "", "groups records by expression, groups have the key, count and items fields")
+	flags.StringVar(&opts.project, "map", "", "maps records by expression or object like `{name, age, adult: age >= 18}`")
+	flags.StringVar(&opts.sort, "sort", "", "sorts records by expression, `-age` sorts descending")
+	flags.IntVar(&opts.limit, "limit", -1, "maximum number of records")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	c, err := newCommand(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "go-util:", err)
+		return 2
+	}
+
+	if err := c.run(flags.Args(), stdin, stdout); err != nil {
+		fmt.Fprintln(stderr, "go-util:", err)
+		return 1
+	}
+
+	return 0
+}
+
+type options struct {
+	in, out string
+	query   string
+	filter  string
+	groupBy string
+	project string
+	sort    string
+	limit   int
+}
+
+// one field of `{name, total: price * qty}` like maps
+type projection struct {
+	name string
+	expr *stream.Expr
+}
+
+type command struct {
+	options
+	group      *stream.Expr
+	mapExpr    *stream.Expr
+	projection []projection
+	sortExpr   *stream.Expr
+	descending bool
+	// column order of csv and table output
+	columns []string
+
+	mutex sync.Mutex
+	err   error
+}
+
+// expressions are compiled before any input is read
+func newCommand(opts options) (*command, error) {
+	c := &command{options: opts}
+
+	switch opts.in {
+	case "", "json", "ndjson", "csv":
+	default:
+		return nil, fmt.Errorf("unknown input format %s", opts.in)
+	}
+	switch opts.out {
+	case "json", "ndjson", "csv", "table":
+	default:
+		return nil, fmt.Errorf("unknown output format %s", opts.out)
+	}
+
+	if opts.filter != "" {
+		if _, err := stream.CompileExpr(opts.filter); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if opts.groupBy != "" {
+		if c.group, err = stream.CompileExpr(opts.groupBy); err != nil {
+			return nil, err
+		}
+		c.columns = []string{opts.groupBy, "count", "items"}
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(opts.project), "{") {
+		if c.projection, err = parseProjection(opts.project); err != nil {
+			return nil, err
+		}
+		c.columns = nil
+		for _, p := range c.projection {
+			c.columns = append(c.columns, p.name)
+		}
+	} else if opts.project != "" {
+		if c.mapExpr, err = stream.CompileExpr(opts.project); err != nil {
+			return nil, err
+		}
+		c.columns = nil
+	}
+
+	if opts.sort != "" {
+		expr := opts.sort
+		if strings.HasPrefix(expr, "-") {
+			c.descending, expr = true, expr[1:]
+		}
+		if c.sortExpr, err = stream.CompileExpr(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// `{name, city: address.city, "full name": first + " " + last}`, name of plain fields is their last part
+func parseProjection(src string) ([]projection, error) {
+	body := strings.TrimSpace(src)
+	if !strings.HasSuffix(body, "}") {
+		return nil, fmt.Errorf("map %q: missing }", src)
+	}
+	body = body[1 : len(body)-1]
+
+	var fields []projection
+	for _, part := range stream.SplitTopLevel(body, ',') {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("map %q: empty field", src)
+		}
+
+		name, expr := part, part
+		if kv := stream.SplitTopLevel(part, ':'); len(kv) == 2 {
+			name, expr = strings.TrimSpace(kv[0]), kv[1]
+			if unquoted, err := strconv.Unquote(name); err == nil {
+				name = unquoted
+			}
+		} else if i := strings.LastIndex(part, "."); i >= 0 {
+			name = part[i+1:]
+		}
+
+		e, err := stream.CompileExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, projection{name: name, expr: e})
+	}
+
+	return fields, nil
+}
+
+// keeps first error, records with evaluation error are skipped or have nil fields
+func (c *command) fail(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *command) run(files []string, stdin io.Reader, stdout io.Writer) error {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	sources := &multiIterator{}
+	defer sources.closeFiles()
+	for _, name := range files {
+		it, err := c.open(name, stdin, sources)
+		if err != nil {
+			return err
+		}
+		sources.iterators = append(sources.iterators, it)
+	}
+
+	s := c.apply(stream.OfIterator(sources, []map[string]interface{}{}))
+
+	// records written before an error are kept like other stream tools
+	w := bufio.NewWriter(stdout)
+	var err error
+	switch c.out {
+	case "json":
+		if err = s.WriteJSON(w); err == nil {
+			err = w.WriteByte('\n')
+		}
+	case "ndjson":
+		err = s.WriteNDJSON(w)
+	case "csv":
+		err = c.writeCSV(w, s)
+	default:
+		err = s.RenderTable(w, stream.TableOptions{Columns: c.columns})
+	}
+
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil {
+		err = s.Err()
+	}
+	if err == nil {
+		err = c.err
+	}
+
+	return err
+}
+
+func (c *command) open(name string, stdin io.Reader, sources *multiIterator) (stream.Iterator, error) {
+	var r io.Reader = stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		sources.files = append(sources.files, f)
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+	format := c.in
+	if format == "" {
+		format = detectFormat(name, br)
+	}
+
+	switch format {
+	case "json":
+		return stream.OfJSONArray(br, map[string]interface{}{}).Iterator(), nil
+	case "ndjson":
+		return stream.OfNDJSON(br, map[string]interface{}{}).Iterator(), nil
+	}
+
+	return &csvRecords{records: stream.OfCSV(br, stream.CSVOptions{}).Iterator()}, nil
+}
+
+// by file extension, stdin and other files by first character
+func detectFormat(name string, r *bufio.Reader) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".csv":
+		return "csv"
+	}
+
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return "json"
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = r.ReadByte()
+			continue
+		case '[':
+			return "json"
+		case '{':
+			return "ndjson"
+		}
+		return "csv"
+	}
+}
+
+func (c *command) apply(s stream.IStream) stream.IStream {
+	if c.query != "" {
+		data := s.Interface()
+		c.fail(s.Err())
+		s = stream.Query(data, c.query)
+	}
+
+	if c.filter != "" {
+		s = s.FilterExpr(c.filter, 1)
+	}
+
+	if c.group != nil {
+		s = stream.Of(c.groups(s))
+	}
+
+	if c.projection != nil {
+		s = s.Map(c.toRecord, []map[string]interface{}{}, 1)
+	} else if c.mapExpr != nil {
+		s = s.MapExpr(c.project, []interface{}{}, 1)
+	}
+
+	if c.sortExpr != nil {
+		s = s.SortStableBy(c.compare)
+	}
+
+	if c.limit >= 0 {
+		s = s.Limit(c.limit)
+	}
+
+	return s
+}
+
+// groups in order of first record
+func (c *command) groups(s stream.IStream) []map[string]interface{} {
+	var groups []map[string]interface{}
+	index := map[string]int{}
+
+	v := reflect.ValueOf(s.Interface())
+	c.fail(s.Err())
+	for i := 0; i < v.Len(); i++ {
+		record := v.Index(i).Interface()
+		key, err := c.group.Eval(stream.Content{Data: record})
+		if err != nil {
+			c.fail(err)
+			continue
+		}
+
+		id := fmt.Sprintf("%T:%v", key, key)
+		n, ok := index[id]
+		if !ok {
+			n = len(groups)
+			index[id] = n
+			groups = append(groups, map[string]interface{}{c.groupBy: key, "count": 0, "items": []interface{}{}})
+		}
+		groups[n]["count"] = groups[n]["count"].(int) + 1
+		groups[n]["items"] = append(groups[n]["items"].([]interface{}), record)
+	}
+
+	if groups == nil {
+		return []map[string]interface{}{}
+	}
+
+	return groups
+}
+
+func (c *command) toRecord(content stream.Content) stream.Content {
+	record := map[string]interface{}{}
+	for _, p := range c.projection {
+		v, err := p.expr.Eval(content)
+		if err != nil {
+			c.fail(err)
+		}
+		record[p.name] = v
+	}
+
+	return stream.Content{Key: content.Key, Data: record}
+}
+
+func (c *command) compare(c1 stream.Content, c2 stream.Content) int {
+	result, err := c.sortExpr.Compare(c1, c2)
+	if err != nil {
+		c.fail(err)
+	}
+
+	// positive result puts c1 first
+	if c.descending {
+		return result
+	}
+
+	return -result
+}
+
+// columns are map fields or "value" for other records
+func (c *command) writeCSV(w io.Writer, s stream.IStream) error {
+	var rows []interface{}
+	v := reflect.ValueOf(s.Interface())
+	for i := 0; i < v.Len(); i++ {
+		rows = append(rows, v.Index(i).Interface())
+	}
+
+	columns := c.columns
+	if columns == nil {
+		columns = columnsOf(rows)
+	}
+
+	records := [][]string{columns}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		m, ok := row.(map[string]interface{})
+		for i, column := range columns {
+			if !ok {
+				record[i] = format(row)
+			} else if value, found := m[column]; found {
+				record[i] = format(value)
+			}
+		}
+		records = append(records, record)
+	}
+
+	return csv.NewWriter(w).WriteAll(records)
+}
+
+// fields of maps in order of first record, fields of one record are sorted
+func columnsOf(rows []interface{}) []string {
+	var columns []string
+	seen := map[string]bool{}
+	for _, row := range rows {
+		m, ok := row.(map[string]interface{})
+		if !ok {
+			if !seen["value"] {
+				seen["value"] = true
+				columns = append(columns, "value")
+			}
+			continue
+		}
+
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			if !seen[k] {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			seen[k] = true
+			columns = append(columns, k)
+		}
+	}
+
+	return columns
+}
+
+func format(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(b)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// pulls inputs one after another, read error of an input ends all
+type multiIterator struct {
+	iterators []stream.Iterator
+	files     []*os.File
+	err       error
+}
+
+func (m *multiIterator) Next() (stream.Content, bool) {
+	for len(m.iterators) > 0 {
+		if content, ok := m.iterators[0].Next(); ok {
+			return content, true
+		}
+
+		if err := m.iterators[0].Close(); err != nil {
+			m.err = err
+			m.iterators = nil
+			return stream.Content{}, false
+		}
+		m.iterators = m.iterators[1:]
+	}
+
+	return stream.Content{}, false
+}
+
+func (m *multiIterator) Close() error {
+	for _, it := range m.iterators {
+		_ = it.Close()
+	}
+	m.iterators = nil
+
+	return m.err
+}
+
+func (m *multiIterator) closeFiles() {
+	for _, f := range m.files {
+		_ = f.Close()
+	}
+}
+
+// maps csv records to header fields, numbers and booleans are converted
+type csvRecords struct {
+	records stream.Iterator
+	header  []string
+}
+
+func (it *csvRecords) Next() (stream.Content, bool) {
+	content, ok := it.records.Next()
+	if ok && it.header == nil {
+		it.header = content.Data.([]string)
+		content, ok = it.records.Next()
+	}
+	if !ok {
+		return stream.Content{}, false
+	}
+
+	// csv reader checks that records have the same number of fields
+	values := content.Data.([]string)
+	record := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		record[it.header[i]] = parseValue(value)
+	}
+
+	return stream.Content{Key: content.Key, Data: record}, true
+}
+
+func (it *csvRecords) Close() error {
+	return it.records.Close()
+}
+
+func parseValue(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return value
 }
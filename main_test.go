@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test CLI", func() {
+	var stdout, stderr *bytes.Buffer
+	var stdin *strings.Reader
+
+	BeforeEach(func() {
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+		stdin = strings.NewReader("")
+	})
+
+	execute := func(args ...string) int {
+		return run(args, stdin, stdout, stderr)
+	}
+
+	It("should filter, map, sort and limit json", func() {
+		code := execute("--filter", "age > 30", "--map", "{name, age}", "--sort", "name", "--limit", "2", "testdata/people.json")
+		Expect(code).To(Equal(0), stderr.String())
+		Expect(stdout.String()).To(Equal(`[{"age":34,"name":"ali"},{"age":41,"name":"ayse"}]` + "\n"))
+	})
+	It("should read files one after another", func() {
+		code := execute("--map", "name", "--sort", "_", "--out", "ndjson", "testdata/people.json", "testdata/people.ndjson")
+		Expect(code).To(Equal(0), stderr.String())
+		Expect(stdout.String()).To(Equal("\"ali\"\n\"ayse\"\n\"can\"\n\"fatma\"\n\"mehmet\"\n\"veli\"\n\"zeynep\"\n"))
+	})
+	It("should read csv from stdin", func() {
+		data, err := ioutil.ReadFile("testdata/people.csv")
+		Expect(err).To(BeNil())
+		stdin = strings.NewReader(string(data))
+
+		code := execute("--filter", "city == 'ankara'", "--map", "{name, next: age + 1}", "--out", "csv")
+		Expect(code).To(Equal(0), stderr.String())
+		Expect(stdout.String()).To(Equal("name,next\nveli,29\nmehmet,53\n"))
+	})
+	It("should group records", func() {
+		code := execute("--query", "SELECT * FROM . ORDER BY age DESC", "--group-by", "city", "--map", "{city, count, oldest: items[0].age}", "--sort", "-count", "--out", "table", "testdata/people.csv")
+		Expect(code).To(Equal(0), stderr.String())
+		Expect(stdout.String()).To(Equal(
+			"city      count  oldest\n" +
+				"ankara    2      52\n" +
+				"istanbul  2      41\n" +
+				"izmir     1      25\n"))
+
+		stdout.Reset()
+		code = execute("--group-by", "city", "--limit", "1", "--out", "ndjson", "testdata/people.ndjson")
+		Expect(code).To(Equal(0), stderr.String())
+		Expect(stdout.String()).To(Equal(`{"city":"izmir","count":1,"items":[{"age":31,"city":"izmir","name":"zeynep"}]}` + "\n"))
+	})
+	It("should run query", func() {
+		code := execute("--query", "SELECT name FROM . WHERE age < 30 ORDER BY age", "--out", "table", "testdata/people.json")
+		Expect(code).To(Equal(0), stderr.String())
+		Expect(stdout.String()).To(Equal("name\nfatma\nveli\n"))
+	})
+	It("should detect format of stdin", func() {
+		stdin = strings.NewReader(`  {"a": 1}` + "\n" + `{"a": 2, "b": "x"}`)
+		code := execute("--out", "table")
+		Expect(code).To(Equal(0), stderr.String())
+		Expect(stdout.String()).To(Equal("a  b\n1\n2  x\n"))
+
+		stdout.Reset()
+		stdin = strings.NewReader("a;b\n1;2\n")
+		code = execute("--in", "csv", "--out", "json")
+		Expect(code).To(Equal(0), stderr.String())
+		Expect(stdout.String()).To(Equal(`[{"a;b":"1;2"}]` + "\n"))
+	})
+	It("should exit with 2 for invalid flags", func() {
+		for _, args := range [][]string{
+			{"--unknown"},
+			{"--out", "xml"},
+			{"--filter", "age >"},
+			{"--map", "{name, }"},
+			{"--sort", "-"},
+		} {
+			stderr.Reset()
+			Expect(execute(args...)).To(Equal(2), strings.Join(args, " "))
+			Expect(stderr.String()).NotTo(BeEmpty())
+		}
+		Expect(stdout.String()).To(BeEmpty())
+	})
+	It("should exit with 1 for read errors", func() {
+		Expect(execute("testdata/missing.json")).To(Equal(1))
+		Expect(stderr.String()).To(ContainSubstring("missing.json"))
+
+		stderr.Reset()
+		Expect(execute("testdata/broken.json")).To(Equal(1))
+		Expect(stderr.String()).To(ContainSubstring("unexpected EOF"))
+
+		stderr.Reset()
+		Expect(execute("--map", "{n: name + 1}", "testdata/people.json")).To(Equal(1))
+		Expect(stderr.String()).To(ContainSubstring("name + 1"))
+	})
+})
@@ -0,0 +1,121 @@
+package stream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+)
+
+// 64 bit hash of data, numbers and strings are hashed by value others by %#v
+func hashData(data interface{}) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+
+	v := reflect.ValueOf(data)
+	switch v.Kind() {
+	case reflect.String:
+		h.Write([]byte{1})
+		h.Write([]byte(v.String()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Int()))
+		h.Write([]byte{2})
+		h.Write(buf[:])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.LittleEndian.PutUint64(buf[:], v.Uint())
+		h.Write([]byte{3})
+		h.Write(buf[:])
+	case reflect.Float32, reflect.Float64:
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v.Float()))
+		h.Write([]byte{4})
+		h.Write(buf[:])
+	default:
+		fmt.Fprintf(h, "%#v", data)
+	}
+
+	// fnv does not spread short keys to high bits, finalize like murmur3
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return x
+}
+
+func approxDistinctCount(length int, content func(i int) Content, relativeError float64, workerCount int) int {
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	precision := hyperLogLogPrecision(relativeError)
+	sketches := make([]*hyperLogLog, workerCount)
+	parallelChunks(length, workerCount, func(worker, st, end int) {
+		sketch := newHyperLogLog(precision)
+		for i := st; i < end; i++ {
+			sketch.add(hashData(content(i).Data))
+		}
+		sketches[worker] = sketch
+	})
+
+	merged := newHyperLogLog(precision)
+	for _, sketch := range sketches {
+		if sketch != nil {
+			merged.merge(sketch)
+		}
+	}
+
+	return merged.count()
+}
+
+func approxTopK(length int, content func(i int) Content, k int, epsilon, delta float64, workerCount int) []Frequency {
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	sketches := make([]*heavyHitters, workerCount)
+	parallelChunks(length, workerCount, func(worker, st, end int) {
+		sketch := newHeavyHitters(k, epsilon, delta)
+		for i := st; i < end; i++ {
+			data := content(i).Data
+			sketch.add(hashData(data), data)
+		}
+		sketches[worker] = sketch
+	})
+
+	merged := newHeavyHitters(k, epsilon, delta)
+	for _, sketch := range sketches {
+		if sketch != nil {
+			merged.merge(sketch)
+		}
+	}
+
+	return merged.frequencies()
+}
+
+func approxQuantile(length int, content func(i int) Content, q, compression float64, workerCount int, f ...Extract) float64 {
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	extract := getExtract(f...)
+	digests := make([]*tDigest, workerCount)
+	parallelChunks(length, workerCount, func(worker, st, end int) {
+		digest := newTDigest(compression)
+		for i := st; i < end; i++ {
+			digest.add(extract(content(i)))
+		}
+		digests[worker] = digest
+	})
+
+	merged := newTDigest(compression)
+	for _, digest := range digests {
+		if digest != nil {
+			merged.merge(digest)
+		}
+	}
+
+	return merged.quantile(q)
+}
@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Approx", func() {
+	var values []int
+	var content func(i int) Content
+	BeforeEach(func() {
+		r := rand.New(rand.NewSource(42))
+		values = make([]int, 100000)
+		for i := range values {
+			values[i] = r.Intn(30000)
+		}
+		content = func(i int) Content {
+			return Content{Data: values[i]}
+		}
+	})
+	Describe("HyperLogLog", func() {
+		It("should choose precision from error", func() {
+			Expect(hyperLogLogPrecision(0.01)).To(Equal(uint8(14)))
+			Expect(hyperLogLogPrecision(0.5)).To(Equal(uint8(4)))
+			Expect(hyperLogLogPrecision(0.0001)).To(Equal(uint8(18)))
+		})
+		It("should count distinct within error bound", func() {
+			exact := map[int]bool{}
+			for _, v := range values {
+				exact[v] = true
+			}
+			for _, workerCount := range []int{1, 4} {
+				count := approxDistinctCount(len(values), content, 0.02, workerCount)
+				Expect(math.Abs(float64(count-len(exact))) / float64(len(exact))).To(BeNumerically("<", 3*0.02))
+			}
+		})
+		It("should count small sets exactly", func() {
+			Expect(Of([]string{"a", "b", "a", "c"}).ApproxDistinctCount(0.01)).To(Equal(3))
+		})
+	})
+	Describe("Count-min", func() {
+		It("should never undercount", func() {
+			sketch := newCountMinSketch(0.01, 0.01)
+			exact := map[int]int{}
+			for _, v := range values {
+				sketch.add(hashData(v))
+				exact[v]++
+			}
+			for v, c := range exact {
+				estimate := sketch.estimate(hashData(v))
+				Expect(estimate >= c).To(BeTrue())
+				Expect(estimate - c).To(BeNumerically("<=", int(0.01*float64(len(values)))+1))
+			}
+		})
+		It("should find heavy hitters", func() {
+			var skewed []int
+			for i := 1; i <= 50; i++ {
+				for j := 0; j < 1000/i; j++ {
+					skewed = append(skewed, i)
+				}
+			}
+			rand.New(rand.NewSource(1)).Shuffle(len(skewed), func(i, j int) {
+				skewed[i], skewed[j] = skewed[j], skewed[i]
+			})
+			skewedContent := func(i int) Content {
+				return Content{Data: skewed[i]}
+			}
+			for _, workerCount := range []int{1, 4} {
+				top := approxTopK(len(skewed), skewedContent, 3, 0.001, 0.01, workerCount)
+				Expect(len(top)).To(Equal(3))
+				Expect(top[0]).To(Equal(Frequency{Data: 1, Count: 1000}))
+				Expect(top[1].Data).To(Equal(2))
+				Expect(top[2].Data).To(Equal(3))
+				Expect(top[1].Count).To(BeNumerically(">=", 500))
+			}
+		})
+		It("should get top k of stream", func() {
+			top := Of(map[string]string{"a": "x", "b": "y", "c": "x"}).ApproxTopK(1, 0, 0)
+			Expect(top).To(Equal([]Frequency{{Data: "x", Count: 2}}))
+		})
+	})
+	Describe("t-digest", func() {
+		It("should estimate quantiles within rank error", func() {
+			sorted := make([]float64, len(values))
+			for i, v := range values {
+				sorted[i] = float64(v)
+			}
+			sort.Float64s(sorted)
+			for _, workerCount := range []int{1, 4} {
+				for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+					estimate := approxQuantile(len(values), content, q, 100, workerCount)
+					rank := float64(sort.SearchFloat64s(sorted, estimate)) / float64(len(sorted))
+					Expect(math.Abs(rank - q)).To(BeNumerically("<", 0.01))
+				}
+			}
+		})
+		It("should get min and max", func() {
+			digest := newTDigest(100)
+			for _, v := range values {
+				digest.add(float64(v))
+			}
+			Expect(digest.quantile(0)).To(Equal(0.0))
+			Expect(digest.quantile(1)).To(Equal(29999.0))
+		})
+		It("should get quantile of field", func() {
+			res := Of([]testModel{{Id: 1}, {Id: 2}, {Id: 3}}).ApproxQuantile(0.5, 0, func(content Content) float64 {
+				return float64(content.Data.(testModel).Id)
+			})
+			Expect(res).To(Equal(2.0))
+		})
+		It("should get NaN when empty", func() {
+			Expect(math.IsNaN(Of([]int{}).ApproxQuantile(0.5, 0))).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,234 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// error of elements rejected by an open breaker without Fallback
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return "closed"
+}
+
+// breaker of stages after Breaker, it can be shared by stages and streams calling same dependency.
+// it opens after MaxFailures consecutive failures or when failure rate of calls in last Window
+// reaches FailureRate. after OpenTimeout one call is let through as a probe, its success closes breaker
+type CircuitBreaker struct {
+	// consecutive failures that open breaker, 0 disables it
+	MaxFailures int
+	// between 0 and 1, 0 disables it
+	FailureRate float64
+	Window      time.Duration
+	// calls in Window before FailureRate is checked
+	MinRequests int
+	// time breaker stays open before a probe
+	OpenTimeout time.Duration
+	// result of elements while breaker is open, default fails them with ErrBreakerOpen
+	Fallback Action
+	// called on every transition, parallel stages can call it from several goroutines
+	OnStateChange func(from, to BreakerState)
+	// default is system clock
+	Clock Clock
+
+	mutex    sync.Mutex
+	state    BreakerState
+	failures int
+	calls    []breakerCall
+	openedAt time.Time
+	probing  bool
+}
+
+type breakerCall struct {
+	at     time.Time
+	failed bool
+}
+
+type transition struct {
+	from, to BreakerState
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.state
+}
+
+// allowed calls go to dependency, probe is the call of half-open breaker
+func (b *CircuitBreaker) allow() (allowed, probe bool) {
+	b.mutex.Lock()
+	var changes []transition
+	defer func() {
+		b.mutex.Unlock()
+		b.notify(changes)
+	}()
+
+	switch b.state {
+	case BreakerOpen:
+		if clockOrSystem(b.Clock).Now().Sub(b.openedAt) < b.OpenTimeout {
+			return false, false
+		}
+		changes = b.setState(BreakerHalfOpen, changes)
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+
+	return true, false
+}
+
+func (b *CircuitBreaker) done(probe, failed bool) {
+	b.mutex.Lock()
+	var changes []transition
+	defer func() {
+		b.mutex.Unlock()
+		b.notify(changes)
+	}()
+
+	now := clockOrSystem(b.Clock).Now()
+	if probe {
+		b.probing = false
+		if failed {
+			changes = b.open(now, changes)
+		} else {
+			changes = b.setState(BreakerClosed, changes)
+		}
+		return
+	}
+
+	// calls which started before breaker opened
+	if b.state != BreakerClosed {
+		return
+	}
+
+	if failed {
+		b.failures++
+	} else {
+		b.failures = 0
+	}
+
+	if b.MaxFailures > 0 && b.failures >= b.MaxFailures {
+		changes = b.open(now, changes)
+	} else if b.FailureRate > 0 && b.failureRate(now, failed) >= b.FailureRate {
+		changes = b.open(now, changes)
+	}
+}
+
+// records call and gives rate of failed calls in window, 0 when there are not enough calls
+func (b *CircuitBreaker) failureRate(now time.Time, failed bool) float64 {
+	b.calls = append(b.calls, breakerCall{at: now, failed: failed})
+
+	i := 0
+	for i < len(b.calls) && b.Window > 0 && now.Sub(b.calls[i].at) >= b.Window {
+		i++
+	}
+	b.calls = b.calls[i:]
+
+	if len(b.calls) == 0 || len(b.calls) < b.MinRequests {
+		return 0
+	}
+
+	failures := 0
+	for _, call := range b.calls {
+		if call.failed {
+			failures++
+		}
+	}
+
+	return float64(failures) / float64(len(b.calls))
+}
+
+func (b *CircuitBreaker) open(now time.Time, changes []transition) []transition {
+	b.openedAt = now
+	return b.setState(BreakerOpen, changes)
+}
+
+func (b *CircuitBreaker) setState(state BreakerState, changes []transition) []transition {
+	b.failures = 0
+	b.calls = nil
+	if b.state == state {
+		return changes
+	}
+
+	changes = append(changes, transition{from: b.state, to: state})
+	b.state = state
+
+	return changes
+}
+
+// hooks are called without lock so they can read State
+func (b *CircuitBreaker) notify(changes []transition) {
+	if b.OnStateChange == nil {
+		return
+	}
+
+	for _, change := range changes {
+		b.OnStateChange(change.from, change.to)
+	}
+}
+
+func (p *pipeline) setBreaker(b *CircuitBreaker) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.breaker = b
+}
+
+func (p *pipeline) breakerOf() *CircuitBreaker {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.breaker
+}
+
+// calls go through breaker of stream, errors and panics of f are failures
+func (p *pipeline) broken(st *stage, f TryAction) TryAction {
+	b := p.breakerOf()
+	if b == nil {
+		return f
+	}
+
+	return func(c Content) (result Content, err error) {
+		allowed, probe := b.allow()
+		if !allowed {
+			atomic.AddInt64(&st.rejected, 1)
+			if b.Fallback != nil {
+				return b.Fallback(c), nil
+			}
+			return Content{}, ErrBreakerOpen
+		}
+
+		failed := true
+		defer func() {
+			b.done(probe, failed)
+		}()
+
+		result, err = f(c)
+		failed = err != nil
+
+		return result, err
+	}
+}
@@ -0,0 +1,147 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Breaker", func() {
+	errDown := errors.New("down")
+	down := false
+	calls := 0
+	call := func(content Content) (Content, error) {
+		calls++
+		if down {
+			return Content{}, errDown
+		}
+		return content, nil
+	}
+
+	var clock *fakeClock
+	var changes []string
+	hook := func(from, to BreakerState) {
+		changes = append(changes, fmt.Sprintf("%s>%s", from, to))
+	}
+
+	BeforeEach(func() {
+		down, calls = false, 0
+		clock = &fakeClock{}
+		changes = nil
+	})
+
+	It("should open after consecutive failures and close after probe", func() {
+		b := &CircuitBreaker{MaxFailures: 2, OpenTimeout: time.Second, OnStateChange: hook, Clock: clock}
+
+		down = true
+		s := Of([]int{1, 2, 3, 4}).Breaker(b).TryMap(call, []int{})
+		Expect(s.Interface()).To(Equal([]int{}))
+		Expect(s.Err()).To(Equal(errDown))
+		Expect(calls).To(Equal(2))
+		Expect(b.State()).To(Equal(BreakerOpen))
+		Expect(s.Trace()).To(Equal([]StageTrace{{Name: "TryMap 1", Rejected: 2}}))
+
+		clock.Sleep(time.Second)
+		s = Of([]int{5}).Breaker(b).TryMap(call, []int{})
+		Expect(s.Interface()).To(Equal([]int{}))
+		Expect(b.State()).To(Equal(BreakerOpen))
+
+		down = false
+		s = Of([]int{6, 7}).Breaker(b).TryMap(call, []int{})
+		Expect(s.Interface()).To(Equal([]int{}))
+		Expect(s.Err()).To(Equal(ErrBreakerOpen))
+
+		clock.Sleep(time.Second)
+		s = Of([]int{8, 9}).Breaker(b).TryMap(call, []int{})
+		Expect(s.Interface()).To(Equal([]int{8, 9}))
+		Expect(b.State()).To(Equal(BreakerClosed))
+
+		Expect(changes).To(Equal([]string{
+			"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed",
+		}))
+	})
+	It("should open on failure rate and use fallback", func() {
+		b := &CircuitBreaker{
+			FailureRate: 0.5,
+			Window:      10 * time.Second,
+			MinRequests: 4,
+			OpenTimeout: time.Minute,
+			Fallback: func(content Content) Content {
+				return Content{Data: -1}
+			},
+			OnStateChange: hook,
+			Clock:         clock,
+		}
+		var letters []DeadLetter
+		s := Of([]int{1, 2, 3, 4, 5, 6}).
+			DeadLetter(DeadLetterSlice(&letters)).
+			Breaker(b).
+			TryMap(func(content Content) (Content, error) {
+				if content.Data.(int)%2 == 0 {
+					return Content{}, errDown
+				}
+				return content, nil
+			}, []int{})
+
+		Expect(s.Interface()).To(Equal([]int{1, 3, -1, -1}))
+		Expect(letters).To(HaveLen(2))
+		Expect(s.Trace()[0].Rejected).To(Equal(2))
+		Expect(changes).To(Equal([]string{"closed>open"}))
+	})
+	It("should forget failures out of window", func() {
+		b := &CircuitBreaker{FailureRate: 0.5, Window: time.Second, MinRequests: 2, Clock: clock}
+		s := Of([]int{1, 2, 3}).Breaker(b).TryMap(func(content Content) (Content, error) {
+			defer clock.Sleep(time.Second)
+			if content.Data.(int) != 2 {
+				return Content{}, errDown
+			}
+			return content, nil
+		}, []int{})
+
+		Expect(s.Interface()).To(Equal([]int{2}))
+		Expect(b.State()).To(Equal(BreakerClosed))
+	})
+	It("should count panics of Map and stop retries", func() {
+		b := &CircuitBreaker{MaxFailures: 1, OpenTimeout: time.Second, Clock: clock}
+		var letters []DeadLetter
+		s := Range(0, 3, 1).
+			DeadLetter(DeadLetterSlice(&letters)).
+			Breaker(b).
+			Map(func(content Content) Content {
+				panic("down")
+			}, []int{})
+
+		Expect(s.Count()).To(Equal(0))
+		Expect(letters).To(HaveLen(3))
+		Expect(letters[0].Err).To(MatchError("panic: down"))
+		Expect(letters[2].Err).To(Equal(ErrBreakerOpen))
+
+		down = true
+		b = &CircuitBreaker{MaxFailures: 1, OpenTimeout: time.Second, Clock: clock}
+		s = Of([]int{1}).Breaker(b).MapWithRetry(call, []int{}, RetryPolicy{Max: 5, Clock: clock})
+		Expect(s.Count()).To(Equal(0))
+		Expect(calls).To(Equal(1))
+		Expect(s.Trace()).To(Equal([]StageTrace{{Name: "MapWithRetry 1", Retries: 1, Rejected: 1}}))
+	})
+	It("should let one probe through in parallel", func() {
+		b := &CircuitBreaker{MaxFailures: 1, OpenTimeout: time.Second, Clock: clock}
+		down = true
+		Of([]int{1}).Breaker(b).TryMap(call, []int{}).Count()
+		clock.Sleep(time.Second)
+
+		allowed, probe := b.allow()
+		Expect(allowed && probe).To(BeTrue())
+		allowed, _ = b.allow()
+		Expect(allowed).To(BeFalse())
+		b.done(true, false)
+		Expect(b.State()).To(Equal(BreakerClosed))
+
+		s := Range(0, 20, 1).Breaker(b).TryMap(func(content Content) (Content, error) {
+			return content, nil
+		}, []int{}, 4)
+		Expect(s.Count()).To(Equal(20))
+	})
+})
@@ -0,0 +1,27 @@
+package stream
+
+import "time"
+
+// time source of waiting stages, tests can give a fake one which does not sleep
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return systemClock{}
+	}
+
+	return clock
+}
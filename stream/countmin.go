@@ -0,0 +1,182 @@
+package stream
+
+import (
+	"container/heap"
+	"math"
+)
+
+// count-min sketch, estimates never undercount.
+// with width e/epsilon and depth ln(1/delta) overcount is below epsilon*total with probability 1-delta
+type countMinSketch struct {
+	width int
+	depth int
+	table [][]int
+}
+
+func newCountMinSketch(epsilon, delta float64) *countMinSketch {
+	if epsilon <= 0 {
+		epsilon = 0.001
+	}
+	if delta <= 0 || delta >= 1 {
+		delta = 0.01
+	}
+
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	table := make([][]int, depth)
+	for i := range table {
+		table[i] = make([]int, width)
+	}
+
+	return &countMinSketch{
+		width: width,
+		depth: depth,
+		table: table,
+	}
+}
+
+// row index from two halves of the hash (Kirsch-Mitzenmacher)
+func (s *countMinSketch) column(hash uint64, row int) int {
+	h1 := hash & 0xffffffff
+	h2 := hash >> 32
+
+	return int((h1 + uint64(row)*h2) % uint64(s.width))
+}
+
+func (s *countMinSketch) add(hash uint64) int {
+	estimate := math.MaxInt64
+	for row := range s.table {
+		c := s.column(hash, row)
+		s.table[row][c]++
+		if s.table[row][c] < estimate {
+			estimate = s.table[row][c]
+		}
+	}
+
+	return estimate
+}
+
+func (s *countMinSketch) estimate(hash uint64) int {
+	estimate := math.MaxInt64
+	for row := range s.table {
+		if v := s.table[row][s.column(hash, row)]; v < estimate {
+			estimate = v
+		}
+	}
+
+	return estimate
+}
+
+// sketches should have same width and depth
+func (s *countMinSketch) merge(o *countMinSketch) {
+	for row := range s.table {
+		for c, v := range o.table[row] {
+			s.table[row][c] += v
+		}
+	}
+}
+
+// Frequency of a value
+type Frequency struct {
+	Data  interface{}
+	Count int
+}
+
+type heavyHitter struct {
+	hash  uint64
+	data  interface{}
+	count int
+	index int
+}
+
+// count-min sketch that keeps k most frequent values in a min heap
+type heavyHitters struct {
+	k      int
+	sketch *countMinSketch
+	items  []*heavyHitter
+	byHash map[uint64]*heavyHitter
+}
+
+func newHeavyHitters(k int, epsilon, delta float64) *heavyHitters {
+	return &heavyHitters{
+		k:      k,
+		sketch: newCountMinSketch(epsilon, delta),
+		byHash: map[uint64]*heavyHitter{},
+	}
+}
+
+func (h *heavyHitters) Len() int { return len(h.items) }
+
+func (h *heavyHitters) Less(i, j int) bool { return h.items[i].count < h.items[j].count }
+
+func (h *heavyHitters) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *heavyHitters) Push(x interface{}) {
+	item := x.(*heavyHitter)
+	item.index = len(h.items)
+	h.items = append(h.items, item)
+}
+
+func (h *heavyHitters) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+
+	return last
+}
+
+func (h *heavyHitters) offer(hash uint64, data interface{}, count int) {
+	if h.k <= 0 {
+		return
+	}
+
+	if item, ok := h.byHash[hash]; ok {
+		item.count = count
+		heap.Fix(h, item.index)
+		return
+	}
+
+	if len(h.items) < h.k {
+		item := &heavyHitter{hash: hash, data: data, count: count}
+		h.byHash[hash] = item
+		heap.Push(h, item)
+		return
+	}
+
+	if root := h.items[0]; count > root.count {
+		delete(h.byHash, root.hash)
+		root.hash, root.data, root.count = hash, data, count
+		h.byHash[hash] = root
+		heap.Fix(h, 0)
+	}
+}
+
+func (h *heavyHitters) add(hash uint64, data interface{}) {
+	h.offer(hash, data, h.sketch.add(hash))
+}
+
+// merge sketches then estimate candidates of both again
+func (h *heavyHitters) merge(o *heavyHitters) {
+	h.sketch.merge(o.sketch)
+
+	candidates := append(append([]*heavyHitter{}, h.items...), o.items...)
+	h.items = nil
+	h.byHash = map[uint64]*heavyHitter{}
+	for _, c := range candidates {
+		h.offer(c.hash, c.data, h.sketch.estimate(c.hash))
+	}
+}
+
+// most frequent first
+func (h *heavyHitters) frequencies() []Frequency {
+	frequencies := make([]Frequency, len(h.items))
+	for i := len(frequencies) - 1; i >= 0; i-- {
+		item := heap.Pop(h).(*heavyHitter)
+		frequencies[i] = Frequency{Data: item.data, Count: item.count}
+	}
+
+	return frequencies
+}
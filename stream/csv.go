@@ -0,0 +1,405 @@
+package stream
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV reading and writing options, zero value reads and writes comma separated records
+type CSVOptions struct {
+	// field delimiter, default is ','
+	Comma rune
+	// lines starting with it are skipped
+	Comment rune
+	// first record is header, it is not streamed. WriteCSV writes header when it is set
+	Header bool
+	// struct sample, records are mapped to its fields by header.
+	// field name is matched case insensitive or by `csv:"name"` tag, `csv:"-"` is skipped
+	Type interface{}
+	// layout of time fields, default is time.RFC3339
+	TimeFormat string
+	// fmt verb of float fields like "%.2f", default is shortest representation
+	FloatFormat string
+	// header of key column of map streams, default is "key"
+	KeyColumn string
+}
+
+// stream of []string records or structs of options Type.
+// read and conversion errors end the stream and are set to Err of stream
+func OfCSV(r io.Reader, opts CSVOptions) IStream {
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	reader.Comment = opts.Comment
+
+	it := &csvIterator{reader: reader, header: opts.Header, timeFormat: opts.timeFormat()}
+	if opts.Type == nil {
+		return OfIterator(it, [][]string{})
+	}
+
+	typeOf := reflect.TypeOf(opts.Type)
+	if typeOf.Kind() != reflect.Struct {
+		panic("csv type should be struct")
+	}
+	if !opts.Header {
+		panic("csv type needs header")
+	}
+	it.format = typeOf
+
+	return OfIterator(it, reflect.MakeSlice(reflect.SliceOf(typeOf), 0, 0).Interface())
+}
+
+type csvIterator struct {
+	reader     *csv.Reader
+	header     bool
+	format     reflect.Type
+	timeFormat string
+	// field index of every column, -1 for columns without field
+	fields []int
+	line   int
+	done   bool
+	err    error
+}
+
+func (it *csvIterator) Next() (Content, bool) {
+	if it.done {
+		return Content{}, false
+	}
+
+	if it.header && it.line == 0 {
+		header, ok := it.read()
+		if !ok {
+			return Content{}, false
+		}
+		if it.format != nil {
+			it.fields = fieldIndices(it.format, header)
+		}
+	}
+
+	record, ok := it.read()
+	if !ok {
+		return Content{}, false
+	}
+	if it.format == nil {
+		return Content{Data: record}, true
+	}
+
+	item := reflect.New(it.format).Elem()
+	for column, value := range record {
+		if column >= len(it.fields) || it.fields[column] < 0 {
+			continue
+		}
+
+		field := it.format.Field(it.fields[column])
+		if err := setField(item.Field(it.fields[column]), value, it.timeFormat); err != nil {
+			it.done = true
+			it.err = fmt.Errorf("csv record %d field %s: %v", it.line, field.Name, err)
+			return Content{}, false
+		}
+	}
+
+	return Content{Data: item.Interface()}, true
+}
+
+func (it *csvIterator) read() ([]string, bool) {
+	record, err := it.reader.Read()
+	if err != nil {
+		it.done = true
+		if err != io.EOF {
+			it.err = err
+		}
+		return nil, false
+	}
+	it.line++
+
+	return record, true
+}
+
+func (it *csvIterator) Close() error {
+	it.done = true
+	return it.err
+}
+
+func fieldIndices(t reflect.Type, header []string) []int {
+	byName := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Tag.Get("csv")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		byName[strings.ToLower(name)] = i
+	}
+
+	fields := make([]int, len(header))
+	for column, name := range header {
+		index, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			index = -1
+		}
+		fields[column] = index
+	}
+
+	return fields
+}
+
+// sets string value to basic kinds and time, empty value is zero
+func setField(field reflect.Value, value string, timeFormat string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(value)
+		return nil
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Type() == timeType {
+		t, err := time.Parse(timeFormat, value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", field.Kind())
+	}
+
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (opts CSVOptions) timeFormat() string {
+	if opts.TimeFormat == "" {
+		return time.RFC3339
+	}
+
+	return opts.TimeFormat
+}
+
+// column of written rows, field is struct field name and empty for the value itself
+type csvColumn struct {
+	name  string
+	field string
+}
+
+// struct fields are columns, []string elements are written as they are, other values are one column
+func csvColumns(t reflect.Type) []csvColumn {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct || t == timeType {
+		return []csvColumn{{name: "value"}}
+	}
+
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Tag.Get("csv")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, field: field.Name})
+	}
+
+	return columns
+}
+
+// rows are written as elements are pulled. first of write and source errors is returned
+func (p *pipeline) writeCSV(w io.Writer, it Iterator, elemType reflect.Type, keyed bool, opts CSVOptions) error {
+	writer := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		writer.Comma = opts.Comma
+	}
+
+	keyColumn := opts.KeyColumn
+	if keyColumn == "" {
+		keyColumn = "key"
+	}
+
+	var columns []csvColumn
+	var err error
+	started, records := false, false
+	// header is known before first element unless elements are interface,
+	// then columns are found by type of first element and other elements are read by field name
+	start := func(t reflect.Type) {
+		started = true
+		if t == reflect.TypeOf([]string{}) {
+			records = true
+			return
+		}
+
+		columns = csvColumns(t)
+		if !opts.Header {
+			return
+		}
+
+		var header []string
+		if keyed {
+			header = append(header, keyColumn)
+		}
+		for _, column := range columns {
+			header = append(header, column.name)
+		}
+		err = writer.Write(header)
+	}
+
+	if elemType.Kind() != reflect.Interface {
+		start(elemType)
+	}
+
+	closeErr := drain(it, func(c Content) bool {
+		if err != nil {
+			return false
+		}
+		if !started {
+			start(reflect.TypeOf(c.Data))
+			if err != nil {
+				return false
+			}
+		}
+
+		var row []string
+		if keyed {
+			row = append(row, opts.format(reflect.ValueOf(c.Key)))
+		}
+
+		v := reflect.ValueOf(c.Data)
+		if record, ok := c.Data.([]string); ok && records {
+			row = append(row, record...)
+		}
+		for _, column := range columns {
+			row = append(row, opts.format(csvField(v, column.field)))
+		}
+
+		err = writer.Write(row)
+		return err == nil
+	})
+
+	// empty interface stream has value column
+	if !started && err == nil {
+		start(elemType)
+	}
+
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+	if err == nil {
+		err = closeErr
+	}
+
+	p.fail(err)
+
+	return err
+}
+
+// invalid value when element is not a struct or has no such exported field, elements of interface streams may have other types
+func csvField(v reflect.Value, field string) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	if field == "" || !v.IsValid() {
+		return v
+	}
+
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	if f, ok := v.Type().FieldByName(field); !ok || f.PkgPath != "" {
+		return reflect.Value{}
+	}
+
+	return v.FieldByName(field)
+}
+
+// nil is empty, time and floats are formatted by options
+func (opts CSVOptions) format(v reflect.Value) string {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return ""
+	}
+
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(opts.timeFormat())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		if opts.FloatFormat != "" {
+			return fmt.Sprintf(opts.FloatFormat, v.Float())
+		}
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,143 @@
+package stream
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type csvPerson struct {
+	Name   string
+	Age    int     `csv:"age_years"`
+	Score  float64 `csv:"score"`
+	Active bool
+	Secret string `csv:"-"`
+}
+
+type csvRow struct {
+	Name    string    `csv:"name"`
+	Amount  float64   `csv:"amount"`
+	Count   *int      `csv:"count"`
+	Created time.Time `csv:"created"`
+	skipped string
+}
+
+var _ = Describe("Test CSV", func() {
+	Describe("OfCSV", func() {
+		It("should stream records", func() {
+			v := OfCSV(strings.NewReader("a,b\nc,\"d,e\"\n"), CSVOptions{}).Interface()
+			Expect(v).To(Equal([][]string{{"a", "b"}, {"c", "d,e"}}))
+		})
+		It("should skip header and comments", func() {
+			v := OfCSV(strings.NewReader("x;y\n# comment\n1;2\n"), CSVOptions{Comma: ';', Comment: '#', Header: true}).Interface()
+			Expect(v).To(Equal([][]string{{"1", "2"}}))
+		})
+		It("should map records to structs by header", func() {
+			data := "name,age_years,score,active,secret,unknown\n" +
+				"ali,30,1.5,true,x,y\n" +
+				"veli,,2,false,x,y\n"
+			v := OfCSV(strings.NewReader(data), CSVOptions{Header: true, Type: csvPerson{}}).Interface()
+			Expect(v).To(Equal([]csvPerson{
+				{Name: "ali", Age: 30, Score: 1.5, Active: true},
+				{Name: "veli", Score: 2},
+			}))
+		})
+		It("should set conversion error", func() {
+			data := "name,age_years\nali,30\nveli,old\nayse,20\n"
+			s := OfCSV(strings.NewReader(data), CSVOptions{Header: true, Type: csvPerson{}})
+			Expect(s.Interface()).To(Equal([]csvPerson{{Name: "ali", Age: 30}}))
+			Expect(s.Err()).To(HaveOccurred())
+			Expect(s.Err().Error()).To(ContainSubstring("Age"))
+		})
+		It("should set parse error", func() {
+			s := OfCSV(strings.NewReader("a,b\nc\n"), CSVOptions{})
+			Expect(s.Count()).To(Equal(1))
+			Expect(s.Err()).To(HaveOccurred())
+		})
+		It("should panic when type is not struct or header is missing", func() {
+			Expect(func() { OfCSV(strings.NewReader(""), CSVOptions{Header: true, Type: 1}) }).To(Panic())
+			Expect(func() { OfCSV(strings.NewReader(""), CSVOptions{Type: csvPerson{}}) }).To(Panic())
+		})
+	})
+	Describe("WriteCSV", func() {
+		created := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC)
+		count := 3
+
+		It("should write struct rows with header", func() {
+			var buf bytes.Buffer
+			err := Of([]csvRow{
+				{Name: "a", Amount: 1.5, Count: &count, Created: created},
+				{Name: "b, c", Amount: 2},
+			}).WriteCSV(&buf, CSVOptions{Header: true})
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal("name,amount,count,created\n" +
+				"a,1.5,3,2020-05-17T10:30:00Z\n" +
+				"\"b, c\",2,,0001-01-01T00:00:00Z\n"))
+		})
+		It("should format time and floats", func() {
+			var buf bytes.Buffer
+			err := Of([]csvRow{{Name: "a", Amount: 1.234, Created: created}}).
+				WriteCSV(&buf, CSVOptions{Comma: ';', TimeFormat: "2006-01-02", FloatFormat: "%.2f"})
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal("a;1.23;;2020-05-17\n"))
+		})
+		It("should write header of empty stream", func() {
+			var buf bytes.Buffer
+			Expect(Of([]csvPerson{}).WriteCSV(&buf, CSVOptions{Header: true})).To(BeNil())
+			Expect(buf.String()).To(Equal("Name,age_years,score,Active\n"))
+		})
+		It("should write key column of map streams", func() {
+			var buf bytes.Buffer
+			err := Of(map[string]csvPerson{"x": {Name: "ali", Age: 30}}).
+				WriteCSV(&buf, CSVOptions{Header: true, KeyColumn: "id"})
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal("id,Name,age_years,score,Active\nx,ali,30,0,false\n"))
+		})
+		It("should write values and records", func() {
+			var buf bytes.Buffer
+			Expect(Of([]int{1, 2}).WriteCSV(&buf, CSVOptions{Header: true})).To(BeNil())
+			Expect(buf.String()).To(Equal("value\n1\n2\n"))
+
+			buf.Reset()
+			Expect(OfCSV(strings.NewReader("a,b\nc,d\n"), CSVOptions{}).WriteCSV(&buf, CSVOptions{Comma: '\t'})).To(BeNil())
+			Expect(buf.String()).To(Equal("a\tb\nc\td\n"))
+		})
+		It("should use type of first element of interface streams", func() {
+			var buf bytes.Buffer
+			err := Of([]interface{}{csvPerson{Name: "ali"}, &csvPerson{Name: "veli"}}).
+				WriteCSV(&buf, CSVOptions{Header: true})
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal("Name,age_years,score,Active\nali,0,0,false\nveli,0,0,false\n"))
+		})
+		It("should leave fields of other types empty in interface streams", func() {
+			var buf bytes.Buffer
+			err := Of([]interface{}{csvPerson{Name: "ali"}, csvRow{Name: "b", Amount: 2}, 5, nil, []string{"x"}}).
+				WriteCSV(&buf, CSVOptions{Header: true})
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal("Name,age_years,score,Active\nali,0,0,false\nb,,,\n,,,\n,,,\n,,,\n"))
+
+			buf.Reset()
+			err = Of([]interface{}{nil, 1}).WriteCSV(&buf, CSVOptions{Header: true})
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal("value\n\n1\n"))
+		})
+		It("should write header of empty interface stream", func() {
+			var buf bytes.Buffer
+			Expect(Of([]interface{}{}).WriteCSV(&buf, CSVOptions{Header: true})).To(BeNil())
+			Expect(buf.String()).To(Equal("value\n"))
+		})
+		It("should read written rows back", func() {
+			var buf bytes.Buffer
+			rows := []csvRow{{Name: "a", Amount: 1.5, Created: created}}
+			Expect(Of(rows).WriteCSV(&buf, CSVOptions{Header: true})).To(BeNil())
+			v := OfCSV(&buf, CSVOptions{Header: true, Type: csvRow{}}).Interface()
+			Expect(v).To(Equal(rows))
+		})
+		It("should return write error", func() {
+			Expect(Of([]int{1}).WriteCSV(failingWriter{}, CSVOptions{})).To(MatchError("write failed"))
+		})
+	})
+})
@@ -0,0 +1,169 @@
+package stream
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// element that failed in a stage
+type DeadLetter struct {
+	// name of stage in Trace like "Map 2"
+	Stage   string
+	Content Content
+	Err     error
+}
+
+// receives failed elements, parallel stages call it from several goroutines
+type DeadLetterSink func(DeadLetter)
+
+// dead letters are appended to slice, it is safe for parallel stages
+func DeadLetterSlice(letters *[]DeadLetter) DeadLetterSink {
+	var mutex sync.Mutex
+
+	return func(letter DeadLetter) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		*letters = append(*letters, letter)
+	}
+}
+
+// dead letters are sent to channel, stage waits while channel is full
+func DeadLetterChan(ch chan<- DeadLetter) DeadLetterSink {
+	return func(letter DeadLetter) {
+		ch <- letter
+	}
+}
+
+func (p *pipeline) setDeadLetter(sink DeadLetterSink) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.deadLetter = sink
+}
+
+func (p *pipeline) deadLetterSink() DeadLetterSink {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.deadLetter
+}
+
+func (st *stage) send(sink DeadLetterSink, c Content, err error) {
+	atomic.AddInt64(&st.deadLettered, 1)
+	sink(DeadLetter{Stage: st.name, Content: c, Err: err})
+}
+
+func panicError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+
+	return fmt.Errorf("panic: %v", r)
+}
+
+// filter is traced and its panics are dead lettered when stream has a dead letter sink
+func (p *pipeline) guardFilter(f Filter) Filter {
+	if p.deadLetterSink() == nil {
+		return f
+	}
+
+	return p.tryFilter("Filter", func(c Content) (bool, error) {
+		return f(c), nil
+	})
+}
+
+// stream has a dead letter sink, rate limit or breaker for stages added now
+func (p *pipeline) guarded() bool {
+	return p.deadLetterSink() != nil || p.rateLimitOf() != nil || p.breakerOf() != nil
+}
+
+// action is traced and its panics are dead lettered when stream has a dead letter sink,
+// it waits for tokens when stream has a rate limit and goes through breaker of stream
+func (p *pipeline) guardAction(f Action, newType interface{}) Action {
+	if !p.guarded() {
+		return f
+	}
+
+	return p.tryAction("Map", func(c Content) (Content, error) {
+		return f(c), nil
+	}, newType)
+}
+
+// errors and panics go to dead letter sink, without sink first error is set to Err.
+// failed elements are dropped
+func (p *pipeline) tryFilter(operation string, f TryFilter) Filter {
+	st := p.newStage(operation)
+	sink := p.deadLetterSink()
+
+	return func(c Content) (ok bool) {
+		if sink != nil {
+			defer func() {
+				if r := recover(); r != nil {
+					st.send(sink, c, panicError(r))
+					ok = false
+				}
+			}()
+		}
+
+		ok, err := f(c)
+		if err != nil {
+			if sink == nil {
+				p.fail(err)
+			} else {
+				st.send(sink, c, err)
+			}
+			return false
+		}
+
+		return ok
+	}
+}
+
+// like tryFilter, failed elements are mapped to empty newType which Map flattens to nothing
+func (p *pipeline) tryAction(operation string, f TryAction, newType interface{}) Action {
+	st := p.newStage(operation)
+
+	return p.stageAction(st, p.broken(st, p.limited(st, f)), newType)
+}
+
+func (p *pipeline) stageAction(st *stage, f TryAction, newType interface{}) Action {
+	sink := p.deadLetterSink()
+	dropped := emptyOf(reflect.TypeOf(newType))
+
+	return func(c Content) (result Content) {
+		if sink != nil {
+			defer func() {
+				if r := recover(); r != nil {
+					st.send(sink, c, panicError(r))
+					result = dropped
+				}
+			}()
+		}
+
+		result, err := f(c)
+		if err != nil {
+			if sink == nil {
+				p.fail(err)
+			} else {
+				st.send(sink, c, err)
+			}
+			return dropped
+		}
+
+		return result
+	}
+}
+
+func emptyOf(t reflect.Type) Content {
+	switch t.Kind() {
+	case reflect.Map:
+		return Content{Data: reflect.MakeMap(t).Interface()}
+	case reflect.Slice, reflect.Array:
+		return Content{Data: reflect.MakeSlice(reflect.SliceOf(t.Elem()), 0, 0).Interface()}
+	}
+
+	panic("newType should be slice,array or map")
+}
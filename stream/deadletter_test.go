@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test DeadLetter", func() {
+	parse := func(content Content) (Content, error) {
+		var n int
+		if _, err := fmt.Sscanf(content.Data.(string), "%d", &n); err != nil {
+			return Content{}, err
+		}
+		return Content{Data: n}, nil
+	}
+	half := func(content Content) Content {
+		if content.Data.(int)%2 == 1 {
+			panic("odd")
+		}
+		return Content{Data: content.Data.(int) / 2}
+	}
+
+	It("should send failed elements to slice and continue", func() {
+		var letters []DeadLetter
+		s := Of([]string{"1", "x", "4", "6", "y"}).
+			DeadLetter(DeadLetterSlice(&letters)).
+			TryMap(parse, []int{}).
+			Map(half, []int{}).
+			Filter(func(content Content) bool {
+				if content.Data.(int) == 3 {
+					panic(errors.New("three"))
+				}
+				return true
+			})
+
+		Expect(s.Interface()).To(Equal([]int{2}))
+		Expect(s.Err()).To(BeNil())
+		Expect(s.Trace()).To(Equal([]StageTrace{
+			{Name: "TryMap 1", DeadLettered: 2},
+			{Name: "Map 2", DeadLettered: 1},
+			{Name: "Filter 3", DeadLettered: 1},
+		}))
+
+		Expect(letters).To(HaveLen(4))
+		// stages run element by element
+		Expect(letters[0].Stage).To(Equal("Map 2"))
+		Expect(letters[0].Content.Data).To(Equal(1))
+		Expect(letters[0].Err).To(MatchError("panic: odd"))
+		Expect(letters[1].Stage).To(Equal("TryMap 1"))
+		Expect(letters[1].Content.Data).To(Equal("x"))
+		Expect(errors.Unwrap(letters[2].Err)).To(MatchError("three"))
+	})
+	It("should send to channel and callback in parallel stages", func() {
+		ch := make(chan DeadLetter, 100)
+		s := Range(0, 100, 1).
+			DeadLetter(DeadLetterChan(ch)).
+			Map(func(content Content) Content {
+				if content.Data.(int)%10 == 0 {
+					panic("tens")
+				}
+				return content
+			}, []int{}, 4)
+		Expect(s.Count()).To(Equal(90))
+		close(ch)
+		Expect(ch).To(HaveLen(10))
+		Expect(s.Trace()[0].DeadLettered).To(Equal(10))
+
+		var keys []string
+		s = Of(map[string]int{"a": 1, "b": 0, "c": 2}).
+			DeadLetter(func(letter DeadLetter) {
+				keys = append(keys, letter.Content.Key.(string))
+			}).
+			TryFilter(func(content Content) (bool, error) {
+				if content.Data.(int) == 0 {
+					return false, errors.New("zero")
+				}
+				return content.Data.(int) > 1, nil
+			})
+		Expect(s.Interface()).To(Equal(map[string]int{"c": 2}))
+		sort.Strings(keys)
+		Expect(keys).To(Equal([]string{"b"}))
+	})
+	It("should set error without sink", func() {
+		s := Of([]string{"1", "x", "2"}).TryMap(parse, []int{})
+		Expect(s.Interface()).To(Equal([]int{1, 2}))
+		Expect(s.Err()).To(HaveOccurred())
+		Expect(s.Trace()).To(Equal([]StageTrace{{Name: "TryMap 1"}}))
+
+		Expect(func() { Of([]int{1}).Map(half, []int{}).Interface() }).To(Panic())
+	})
+	It("should guard only stages after it", func() {
+		var letters []DeadLetter
+		s := OfChan(make(chan int)).Filter(func(Content) bool { return true })
+		s = s.DeadLetter(DeadLetterSlice(&letters)).Map(half, []int{})
+		Expect(s.Trace()).To(Equal([]StageTrace{{Name: "Map 1"}}))
+	})
+})
@@ -0,0 +1,1083 @@
+package stream
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// compile or evaluation error of an expression, Pos is byte offset in expression
+type ExprError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("expr %q: %s at position %d", e.Expr, e.Msg, e.Pos)
+}
+
+// compiled expression like `Id > 5 && Name != "x"`.
+// identifiers are struct fields or map keys of Data, `_` is Data itself and `_key` is Key of map streams.
+// operators: || && ! == != < <= > >= in, not in, + - * / %, member access a.b and index a[0], lists [1, 2]
+// and functions len lower upper trim contains hasPrefix hasSuffix replace split matches string int float abs min max.
+// SQL like and, or, not, = and <> are accepted, keywords are case insensitive
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+// parses expression once, error has position of the problem
+func CompileExpr(expr string) (*Expr, error) {
+	p := &exprParser{src: expr}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != eofToken {
+		return nil, p.errorf(t.pos, "unexpected %q", t.text)
+	}
+
+	return &Expr{src: expr, root: root}, nil
+}
+
+// value of expression for element, numbers are int64 or float64
+func (e *Expr) Eval(c Content) (interface{}, error) {
+	v, err := e.root.eval(c)
+	if err != nil {
+		if exprErr, ok := err.(*ExprError); ok {
+			exprErr.Expr = e.src
+		}
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// result of expression should be bool
+func (e *Expr) Match(c Content) (bool, error) {
+	v, err := e.Eval(c)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, &ExprError{Expr: e.src, Msg: fmt.Sprintf("result %v is not bool", v)}
+	}
+
+	return b, nil
+}
+
+// compares expression results of elements like ORDER BY of Query, nil is first, then numbers, strings and bools
+func (e *Expr) Compare(c1, c2 Content) (int, error) {
+	v1, err := e.Eval(c1)
+	if err != nil {
+		return 0, err
+	}
+	v2, err := e.Eval(c2)
+	if err != nil {
+		return 0, err
+	}
+
+	return compareSortValues(v1, v2), nil
+}
+
+// compile errors and evaluation errors are set to pipeline, elements with error are skipped
+func (p *pipeline) exprFilter(expr string) Filter {
+	e, err := CompileExpr(expr)
+	if err != nil {
+		p.fail(err)
+		return none
+	}
+
+	return func(c Content) bool {
+		ok, err := e.Match(c)
+		p.fail(err)
+
+		return ok
+	}
+}
+
+// result is converted to element type of newType, it is zero value when evaluation fails
+// compile error is returned without an action
+func (p *pipeline) exprAction(expr string, newType interface{}) (Action, error) {
+	e, err := CompileExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	typeOf := reflect.TypeOf(newType)
+	elem := typeOf.Elem()
+
+	return func(c Content) Content {
+		v, err := e.Eval(c)
+		if err == nil {
+			v, err = convertValue(v, elem)
+		}
+		if err != nil {
+			p.fail(err)
+			v = reflect.Zero(elem).Interface()
+		}
+
+		return Content{Key: c.Key, Data: v}
+	}, nil
+}
+
+func none(Content) bool {
+	return false
+}
+
+// assigns or converts value to type, numbers are converted between kinds
+func convertValue(v interface{}, t reflect.Type) (interface{}, error) {
+	if v == nil {
+		return reflect.Zero(t).Interface(), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(t) {
+		return v, nil
+	}
+
+	_, number := toNumber(v)
+	_, target := toNumber(reflect.Zero(t).Interface())
+	if (number && target) || (rv.Kind() == reflect.String && t.Kind() == reflect.String) {
+		return rv.Convert(t).Interface(), nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %v (%T) to %s", v, v, t)
+}
+
+type exprNode interface {
+	eval(c Content) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(Content) (interface{}, error) {
+	return n.value, nil
+}
+
+type identNode struct {
+	name string
+	pos  int
+}
+
+func (n identNode) eval(c Content) (interface{}, error) {
+	switch n.name {
+	case "_":
+		return normalize(c.Data), nil
+	case "_key":
+		return normalize(c.Key), nil
+	}
+
+	return field(c.Data, n.name, n.pos)
+}
+
+type memberNode struct {
+	x    exprNode
+	name string
+	pos  int
+}
+
+func (n memberNode) eval(c Content) (interface{}, error) {
+	x, err := n.x.eval(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return field(x, n.name, n.pos)
+}
+
+// struct fields should exist, missing map keys and fields of nil are nil
+func field(data interface{}, name string, pos int) (interface{}, error) {
+	v, ok := indirect(reflect.ValueOf(data))
+	if !ok {
+		return nil, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f, ok := v.Type().FieldByName(name)
+		if !ok || f.PkgPath != "" {
+			return nil, &ExprError{Pos: pos, Msg: fmt.Sprintf("unknown field %s of %s", name, v.Type())}
+		}
+		return normalize(v.FieldByIndex(f.Index).Interface()), nil
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return normalize(valueOf(v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())))), nil
+		}
+	}
+
+	return nil, &ExprError{Pos: pos, Msg: fmt.Sprintf("cannot read field %s of %T", name, data)}
+}
+
+type indexNode struct {
+	x, index exprNode
+	pos      int
+}
+
+func (n indexNode) eval(c Content) (interface{}, error) {
+	x, err := n.x.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(c)
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := indirect(reflect.ValueOf(x))
+	if !ok {
+		return nil, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.String:
+		i, ok := index.(int64)
+		if !ok {
+			return nil, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("index %v is not integer", index)}
+		}
+		if i < 0 {
+			i += int64(v.Len())
+		}
+		if i < 0 || i >= int64(v.Len()) {
+			return nil, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("index %v out of range", index)}
+		}
+		if v.Kind() == reflect.String {
+			return string(v.String()[i]), nil
+		}
+		return normalize(v.Index(int(i)).Interface()), nil
+	case reflect.Map:
+		key, err := convertValue(index, v.Type().Key())
+		if err != nil {
+			return nil, &ExprError{Pos: n.pos, Msg: err.Error()}
+		}
+		return normalize(valueOf(v.MapIndex(reflect.ValueOf(key)))), nil
+	}
+
+	return nil, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("cannot index %T", x)}
+}
+
+type listNode struct {
+	items []exprNode
+}
+
+func (n listNode) eval(c Content) (interface{}, error) {
+	values := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+type unaryNode struct {
+	op  string
+	x   exprNode
+	pos int
+}
+
+func (n unaryNode) eval(c Content) (interface{}, error) {
+	x, err := n.x.eval(c)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "!":
+		b, ok := x.(bool)
+		if !ok {
+			return nil, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("operand %v of ! is not bool", x)}
+		}
+		return !b, nil
+	default:
+		switch x := x.(type) {
+		case int64:
+			return -x, nil
+		case float64:
+			return -x, nil
+		}
+		return nil, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("operand %v of - is not number", x)}
+	}
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+	pos         int
+}
+
+func (n binaryNode) eval(c Content) (interface{}, error) {
+	l, err := n.left.eval(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// short circuit
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, n.errorf("left operand %v of %s is not bool", l, n.op)
+		}
+		if lb == (n.op == "||") {
+			return lb, nil
+		}
+
+		r, err := n.right.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, n.errorf("right operand %v of %s is not bool", r, n.op)
+		}
+		return rb, nil
+	}
+
+	r, err := n.right.eval(c)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equalValues(l, r), nil
+	case "!=":
+		return !equalValues(l, r), nil
+	case "<", "<=", ">", ">=":
+		return n.compare(l, r)
+	case "in":
+		return n.in(l, r)
+	case "not in":
+		in, err := n.in(l, r)
+		if err != nil {
+			return nil, err
+		}
+		return !in, nil
+	default:
+		return n.arithmetic(l, r)
+	}
+}
+
+func (n binaryNode) errorf(format string, args ...interface{}) error {
+	return &ExprError{Pos: n.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (n binaryNode) compare(l, r interface{}) (interface{}, error) {
+	var c int
+	lf, lnum := toNumber(l)
+	rf, rnum := toNumber(r)
+	ls, lstr := l.(string)
+	rs, rstr := r.(string)
+
+	switch {
+	case lnum && rnum:
+		c = compareFloat(lf, rf)
+	case lstr && rstr:
+		c = strings.Compare(ls, rs)
+	default:
+		return nil, n.errorf("cannot compare %v (%T) and %v (%T)", l, l, r, r)
+	}
+
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// element of list, key of map or substring of string
+func (n binaryNode) in(l, r interface{}) (bool, error) {
+	v, ok := indirect(reflect.ValueOf(r))
+	if !ok {
+		return false, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if equalValues(l, normalize(v.Index(i).Interface())) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if equalValues(l, normalize(key.Interface())) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.String:
+		s, ok := l.(string)
+		if !ok {
+			return false, n.errorf("left operand %v of in is not string", l)
+		}
+		return strings.Contains(v.String(), s), nil
+	}
+
+	return false, n.errorf("right operand %v of in is not list, map or string", r)
+}
+
+// integers stay integer except division, + concatenates strings
+func (n binaryNode) arithmetic(l, r interface{}) (interface{}, error) {
+	if ls, ok := l.(string); ok && n.op == "+" {
+		if rs, ok := r.(string); ok {
+			return ls + rs, nil
+		}
+	}
+
+	li, lint := l.(int64)
+	ri, rint := r.(int64)
+	if lint && rint && n.op != "/" {
+		switch n.op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "%":
+			if ri == 0 {
+				return nil, n.errorf("division by zero")
+			}
+			return li % ri, nil
+		}
+	}
+
+	lf, lnum := toNumber(l)
+	rf, rnum := toNumber(r)
+	if !lnum || !rnum {
+		return nil, n.errorf("invalid operands %v (%T) and %v (%T) of %s", l, l, r, r, n.op)
+	}
+
+	switch n.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, n.errorf("division by zero")
+		}
+		return lf / rf, nil
+	default:
+		if rf == 0 {
+			return nil, n.errorf("division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	}
+}
+
+// numbers are equal by value
+func equalValues(l, r interface{}) bool {
+	lf, lnum := toNumber(l)
+	rf, rnum := toNumber(r)
+	if lnum && rnum {
+		return lf == rf
+	}
+
+	return reflect.DeepEqual(l, r)
+}
+
+// numbers become int64 or float64
+func normalize(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	}
+
+	return v
+}
+
+type callNode struct {
+	name string
+	f    exprFunc
+	args []exprNode
+	pos  int
+}
+
+func (n callNode) eval(c Content) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	v, err := n.f.call(args)
+	if err != nil {
+		return nil, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("%s: %v", n.name, err)}
+	}
+
+	return v, nil
+}
+
+// max -1 is variadic
+type exprFunc struct {
+	min, max int
+	call     func(args []interface{}) (interface{}, error)
+}
+
+var exprFuncs = map[string]exprFunc{
+	"len": {1, 1, func(args []interface{}) (interface{}, error) {
+		v, ok := indirect(reflect.ValueOf(args[0]))
+		if !ok {
+			return int64(0), nil
+		}
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			return int64(v.Len()), nil
+		}
+		return nil, fmt.Errorf("invalid argument %v", args[0])
+	}},
+	"lower":     stringFunc(func(s []string) interface{} { return strings.ToLower(s[0]) }, 1),
+	"upper":     stringFunc(func(s []string) interface{} { return strings.ToUpper(s[0]) }, 1),
+	"trim":      stringFunc(func(s []string) interface{} { return strings.TrimSpace(s[0]) }, 1),
+	"contains":  stringFunc(func(s []string) interface{} { return strings.Contains(s[0], s[1]) }, 2),
+	"hasPrefix": stringFunc(func(s []string) interface{} { return strings.HasPrefix(s[0], s[1]) }, 2),
+	"hasSuffix": stringFunc(func(s []string) interface{} { return strings.HasSuffix(s[0], s[1]) }, 2),
+	"replace":   stringFunc(func(s []string) interface{} { return strings.Replace(s[0], s[1], s[2], -1) }, 3),
+	"split": stringFunc(func(s []string) interface{} {
+		var values []interface{}
+		for _, part := range strings.Split(s[0], s[1]) {
+			values = append(values, part)
+		}
+		return values
+	}, 2),
+	"matches": {2, 2, func(args []interface{}) (interface{}, error) {
+		s, sok := args[0].(string)
+		pattern, pok := args[1].(string)
+		if !sok || !pok {
+			return nil, fmt.Errorf("arguments should be string")
+		}
+		re, err := compileRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(s), nil
+	}},
+	"string": {1, 1, func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return "", nil
+		}
+		return fmt.Sprint(args[0]), nil
+	}},
+	"int": {1, 1, func(args []interface{}) (interface{}, error) {
+		if s, ok := args[0].(string); ok {
+			return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		}
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %v", args[0])
+		}
+		return int64(f), nil
+	}},
+	"float": {1, 1, func(args []interface{}) (interface{}, error) {
+		if s, ok := args[0].(string); ok {
+			return strconv.ParseFloat(strings.TrimSpace(s), 64)
+		}
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %v", args[0])
+		}
+		return f, nil
+	}},
+	"abs": {1, 1, func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case int64:
+			if v < 0 {
+				return -v, nil
+			}
+			return v, nil
+		case float64:
+			return math.Abs(v), nil
+		}
+		return nil, fmt.Errorf("invalid argument %v", args[0])
+	}},
+	"min": {1, -1, func(args []interface{}) (interface{}, error) { return edgeOf(args, -1) }},
+	"max": {1, -1, func(args []interface{}) (interface{}, error) { return edgeOf(args, 1) }},
+}
+
+func stringFunc(f func([]string) interface{}, n int) exprFunc {
+	return exprFunc{n, n, func(args []interface{}) (interface{}, error) {
+		s := make([]string, len(args))
+		for i, arg := range args {
+			v, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %v is not string", arg)
+			}
+			s[i] = v
+		}
+		return f(s), nil
+	}}
+}
+
+// smallest number when sign is -1, largest when it is 1
+func edgeOf(args []interface{}, sign int) (interface{}, error) {
+	edge := args[0]
+	ef, ok := toNumber(edge)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument %v", edge)
+	}
+
+	for _, arg := range args[1:] {
+		f, ok := toNumber(arg)
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %v", arg)
+		}
+		if compareFloat(f, ef) == sign {
+			edge, ef = arg, f
+		}
+	}
+
+	return edge, nil
+}
+
+var regexps sync.Map
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+
+	return re, nil
+}
+
+type tokenKind int
+
+const (
+	eofToken tokenKind = iota
+	numberToken
+	stringToken
+	identToken
+	operatorToken
+)
+
+type exprToken struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+type exprParser struct {
+	src    string
+	tokens []exprToken
+	index  int
+	// default is exprOperators
+	operators []string
+}
+
+func (p *exprParser) errorf(pos int, format string, args ...interface{}) error {
+	return &ExprError{Expr: p.src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+var exprOperators = []string{"||", "&&", "==", "!=", "<>", "<=", ">=", "=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ",", "."}
+
+func (p *exprParser) lex() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9':
+			st := i
+			isFloat := false
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				if src[i] == '.' || src[i] == 'e' || src[i] == 'E' {
+					isFloat = true
+				}
+				i++
+			}
+			text := src[st:i]
+			var value interface{}
+			var err error
+			if isFloat {
+				value, err = strconv.ParseFloat(text, 64)
+			} else {
+				value, err = strconv.ParseInt(text, 10, 64)
+			}
+			if err != nil {
+				return p.errorf(st, "invalid number %s", text)
+			}
+			p.tokens = append(p.tokens, exprToken{kind: numberToken, text: text, value: value, pos: st})
+		case c == '"' || c == '\'':
+			st := i
+			i++
+			var b strings.Builder
+			closed := false
+			for i < len(src) {
+				if src[i] == byte(c) {
+					closed = true
+					i++
+					break
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(src[i])
+					}
+					i++
+					continue
+				}
+				b.WriteByte(src[i])
+				i++
+			}
+			if !closed {
+				return p.errorf(st, "unterminated string")
+			}
+			p.tokens = append(p.tokens, exprToken{kind: stringToken, text: src[st:i], value: b.String(), pos: st})
+		case c == '_' || unicode.IsLetter(c):
+			st := i
+			for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			p.tokens = append(p.tokens, exprToken{kind: identToken, text: src[st:i], pos: st})
+		default:
+			operators := p.operators
+			if operators == nil {
+				operators = exprOperators
+			}
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					p.tokens = append(p.tokens, exprToken{kind: operatorToken, text: op, pos: i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return p.errorf(i, "unexpected character %q", c)
+			}
+		}
+	}
+
+	p.tokens = append(p.tokens, exprToken{kind: eofToken, text: "end of expression", pos: len(src)})
+
+	return nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.index]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.index]
+	if t.kind != eofToken {
+		p.index++
+	}
+
+	return t
+}
+
+// operator or keyword, keywords are case insensitive
+func (p *exprParser) accept(texts ...string) (exprToken, bool) {
+	t := p.peek()
+	if t.kind != operatorToken && t.kind != identToken {
+		return t, false
+	}
+
+	for _, text := range texts {
+		if t.text == text || (t.kind == identToken && strings.EqualFold(t.text, text)) {
+			p.index++
+			return t, true
+		}
+	}
+
+	return t, false
+}
+
+func (p *exprParser) expect(text string) error {
+	if t, ok := p.accept(text); !ok {
+		return p.errorf(t.pos, "expected %s but found %q", text, t.text)
+	}
+
+	return nil
+}
+
+func (p *exprParser) or() (exprNode, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.accept("||", "or")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "||", left: left, right: right, pos: t.pos}
+	}
+}
+
+func (p *exprParser) and() (exprNode, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.accept("&&", "and")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "&&", left: left, right: right, pos: t.pos}
+	}
+}
+
+func (p *exprParser) not() (exprNode, error) {
+	if t, ok := p.accept("!", "not"); ok {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: "!", x: x, pos: t.pos}, nil
+	}
+
+	return p.comparison()
+}
+
+func (p *exprParser) comparison() (exprNode, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := p.accept("==", "!=", "<>", "<=", ">=", "=", "<", ">", "in", "not")
+	if !ok {
+		return left, nil
+	}
+
+	op := strings.ToLower(t.text)
+	switch op {
+	case "=":
+		op = "=="
+	case "<>":
+		op = "!="
+	case "not":
+		if err := p.expect("in"); err != nil {
+			return nil, err
+		}
+		op = "not in"
+	}
+
+	right, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	return binaryNode{op: op, left: left, right: right, pos: t.pos}, nil
+}
+
+func (p *exprParser) additive() (exprNode, error) {
+	left, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: t.text, left: left, right: right, pos: t.pos}
+	}
+}
+
+func (p *exprParser) multiplicative() (exprNode, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: t.text, left: left, right: right, pos: t.pos}
+	}
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	if t, ok := p.accept("-"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: "-", x: x, pos: t.pos}, nil
+	}
+
+	return p.postfix()
+}
+
+func (p *exprParser) postfix() (exprNode, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("."); ok {
+			t := p.next()
+			if t.kind != identToken {
+				return nil, p.errorf(t.pos, "expected field name but found %q", t.text)
+			}
+			x = memberNode{x: x, name: t.text, pos: t.pos}
+			continue
+		}
+
+		if t, ok := p.accept("["); ok {
+			index, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = indexNode{x: x, index: index, pos: t.pos}
+			continue
+		}
+
+		return x, nil
+	}
+}
+
+func (p *exprParser) primary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case numberToken, stringToken:
+		return literalNode{value: t.value}, nil
+	case identToken:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "nil", "null":
+			return literalNode{value: nil}, nil
+		}
+
+		if _, ok := p.accept("("); ok {
+			return p.call(t)
+		}
+		return identNode{name: t.text, pos: t.pos}, nil
+	case operatorToken:
+		switch t.text {
+		case "(":
+			x, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			items, err := p.list("]")
+			return listNode{items: items}, err
+		}
+	}
+
+	return nil, p.errorf(t.pos, "unexpected %q", t.text)
+}
+
+// function is checked at compile time
+func (p *exprParser) call(name exprToken) (exprNode, error) {
+	f, ok := exprFuncs[name.text]
+	if !ok {
+		return nil, p.errorf(name.pos, "unknown function %s", name.text)
+	}
+
+	args, err := p.list(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < f.min || (f.max >= 0 && len(args) > f.max) {
+		return nil, p.errorf(name.pos, "wrong number of arguments for %s", name.text)
+	}
+
+	return callNode{name: name.text, f: f, args: args, pos: name.pos}, nil
+}
+
+// comma separated expressions until end
+func (p *exprParser) list(end string) ([]exprNode, error) {
+	var items []exprNode
+	if _, ok := p.accept(end); ok {
+		return items, nil
+	}
+
+	for {
+		item, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if _, ok := p.accept(","); !ok {
+			return items, p.expect(end)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package stream
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type exprUser struct {
+	Id      int
+	Name    string
+	Score   float64
+	Tags    []string
+	Address *exprAddress
+}
+
+type exprAddress struct {
+	City string
+}
+
+var _ = Describe("Test Expr", func() {
+	users := []exprUser{
+		{Id: 1, Name: "ali", Score: 1.5, Tags: []string{"admin"}, Address: &exprAddress{City: "istanbul"}},
+		{Id: 5, Name: "x", Score: 3},
+		{Id: 7, Name: "Veli", Score: 4.5, Tags: []string{"dev", "ops"}},
+		{Id: 9, Name: "ayse", Score: 10},
+	}
+
+	eval := func(expr string, data interface{}) interface{} {
+		e, err := CompileExpr(expr)
+		Expect(err).To(BeNil())
+		v, err := e.Eval(Content{Data: data})
+		Expect(err).To(BeNil())
+		return v
+	}
+
+	Describe("CompileExpr", func() {
+		It("should evaluate arithmetic", func() {
+			Expect(eval("1 + 2 * 3", nil)).To(Equal(int64(7)))
+			Expect(eval("(1 + 2) * 3 - -1", nil)).To(Equal(int64(10)))
+			Expect(eval("7 / 2", nil)).To(Equal(3.5))
+			Expect(eval("7 % 3 + 0.5", nil)).To(Equal(1.5))
+			Expect(eval("'a' + \"b\"", nil)).To(Equal("ab"))
+		})
+		It("should evaluate comparison and boolean", func() {
+			Expect(eval("Id > 5 && Name != \"x\"", users[2])).To(Equal(true))
+			Expect(eval("Id > 5 and not (Name == 'Veli')", users[2])).To(Equal(false))
+			Expect(eval("Score >= 3 || Unknown == 1", users[1])).To(Equal(true))
+			Expect(eval("Id == 5.0", users[1])).To(Equal(true))
+			Expect(eval("Name < 'b'", users[0])).To(Equal(true))
+		})
+		It("should evaluate in", func() {
+			Expect(eval("Id in [1, 2, 3]", users[0])).To(Equal(true))
+			Expect(eval("'ops' in Tags", users[2])).To(Equal(true))
+			Expect(eval("'admin' not in Tags", users[2])).To(Equal(true))
+			Expect(eval("'li' in Name", users[0])).To(Equal(true))
+			Expect(eval("'a' in m", map[string]interface{}{"m": map[string]int{"a": 1}})).To(Equal(true))
+		})
+		It("should read members, indices and map keys", func() {
+			Expect(eval("Address.City", users[0])).To(Equal("istanbul"))
+			Expect(eval("Address.City", users[1])).To(BeNil())
+			Expect(eval("Tags[-1]", users[2])).To(Equal("ops"))
+			Expect(eval("user.age + 1", map[string]interface{}{"user": map[string]interface{}{"age": 30.0}})).To(Equal(31.0))
+			Expect(eval("missing == nil", map[string]interface{}{})).To(Equal(true))
+			Expect(eval("_ * 2", 21)).To(Equal(int64(42)))
+		})
+		It("should call functions", func() {
+			Expect(eval("upper(Name) + lower('A')", users[0])).To(Equal("ALIa"))
+			Expect(eval("len(Tags) + len(Name)", users[2])).To(Equal(int64(6)))
+			Expect(eval("hasPrefix(Name, 'a') && hasSuffix(Name, 'i') && contains(Name, 'l')", users[0])).To(Equal(true))
+			Expect(eval("replace(trim('  a-b '), '-', '+')", nil)).To(Equal("a+b"))
+			Expect(eval("split('a,b', ',')[1]", nil)).To(Equal("b"))
+			Expect(eval("matches(Name, '^[a-z]+$')", users[2])).To(Equal(false))
+			Expect(eval("int('12') + int(2.7) + float('0.5')", nil)).To(Equal(14.5))
+			Expect(eval("string(Id) + '!'", users[0])).To(Equal("1!"))
+			Expect(eval("abs(-3) + max(1, 5, 2) - min(4, 2.5)", nil)).To(Equal(5.5))
+		})
+		It("should report compile errors with position", func() {
+			cases := map[string]int{
+				"Id >":          4,
+				"Id > 5 &&":     9,
+				"(Id > 5":       7,
+				"Id # 5":        3,
+				"unknown(Id)":   0,
+				"len(Id, Name)": 0,
+				"'abc":          0,
+				"Id 5":          3,
+				"Tags[0":        6,
+			}
+			for expr, pos := range cases {
+				_, err := CompileExpr(expr)
+				Expect(err).To(HaveOccurred(), expr)
+				Expect(err.(*ExprError).Pos).To(Equal(pos), expr)
+			}
+		})
+		It("should report evaluation errors with position", func() {
+			e, err := CompileExpr("Id > 1 && Unknown == 1")
+			Expect(err).To(BeNil())
+			_, err = e.Eval(Content{Data: users[2]})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*ExprError).Pos).To(Equal(10))
+			Expect(err.Error()).To(ContainSubstring("Unknown"))
+
+			e, _ = CompileExpr("Name + 1")
+			_, err = e.Eval(Content{Data: users[0]})
+			Expect(err.(*ExprError).Pos).To(Equal(5))
+
+			e, _ = CompileExpr("Id")
+			_, err = e.Match(Content{Data: users[0]})
+			Expect(err).To(HaveOccurred())
+		})
+		It("should compare elements", func() {
+			e, _ := CompileExpr("Score * 2")
+			Expect(e.Compare(Content{Data: users[1]}, Content{Data: users[0]})).To(Equal(1))
+			Expect(e.Compare(Content{Data: users[1]}, Content{Data: users[1]})).To(Equal(0))
+
+			// nil is first
+			e, _ = CompileExpr("Address.City")
+			Expect(e.Compare(Content{Data: users[1]}, Content{Data: users[0]})).To(BeNumerically("<", 0))
+
+			e, _ = CompileExpr("Name + 1")
+			_, err := e.Compare(Content{Data: users[0]}, Content{Data: users[1]})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Describe("FilterExpr", func() {
+		It("should filter lists", func() {
+			v := Of(users).FilterExpr(`Id > 5 && Name != "x"`).Interface()
+			Expect(v).To(Equal([]exprUser{users[2], users[3]}))
+		})
+		It("should filter maps by key", func() {
+			v := Of(map[string]int{"a": 1, "b": 2, "c": 3}).FilterExpr("_key != 'b' && _ > 1").Interface()
+			Expect(v).To(Equal(map[string]int{"c": 3}))
+		})
+		It("should set compile error", func() {
+			s := Of(users).FilterExpr("Id >")
+			Expect(s.Err()).To(HaveOccurred())
+			Expect(s.Interface()).To(Equal([]exprUser{}))
+		})
+		It("should set evaluation error and skip element", func() {
+			s := Of([]interface{}{1, "a", 3}).FilterExpr("_ > 1")
+			Expect(s.Interface()).To(Equal([]interface{}{3}))
+			Expect(s.Err()).To(HaveOccurred())
+		})
+	})
+	Describe("MapExpr", func() {
+		It("should map to new type", func() {
+			v := Of(users).FilterExpr("Score > 2").MapExpr("Score * 2", []int{}).Interface()
+			Expect(v).To(Equal([]int{6, 9, 20}))
+
+			v = Of(users).MapExpr("upper(Name)", []string{}).Interface()
+			Expect(v).To(Equal([]string{"ALI", "X", "VELI", "AYSE"}))
+		})
+		It("should keep keys of maps", func() {
+			v := Of(map[string]int{"a": 1, "b": 2}).MapExpr("_key + string(_)", map[string]string{}).Interface()
+			Expect(v).To(Equal(map[string]string{"a": "a1", "b": "b2"}))
+		})
+		It("should map lazy streams", func() {
+			v := Range(0, 5, 1).MapExpr("_ * _", []int{}).Interface()
+			Expect(v).To(Equal([]int{0, 1, 4, 9, 16}))
+		})
+		It("should set errors", func() {
+			s := Of(users).MapExpr("Name +", []string{})
+			Expect(s.Interface()).To(Equal([]string{}))
+			Expect(s.Err()).To(HaveOccurred())
+
+			m := Of([]int{1, 2}).MapExpr("_ +", map[string]int{})
+			Expect(m.Err()).To(HaveOccurred())
+			Expect(m.Interface()).To(Equal(map[string]int{}))
+
+			s = Of(users).MapExpr("Name", []int{})
+			Expect(s.Interface()).To(Equal([]int{0, 0, 0, 0}))
+			Expect(s.Err()).To(HaveOccurred())
+		})
+	})
+})
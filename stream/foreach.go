@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"context"
+	"sync"
+)
+
+// workers pull elements until iterator ends, f fails or ctx is done. first error is returned,
+// elements are not pulled after it and iterator is closed when workers return
+func (p *pipeline) forEach(ctx context.Context, it Iterator, f func(Content) error, workerCount int) error {
+	var mutex sync.Mutex
+	var first error
+	setFirst := func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if first == nil {
+			first = err
+		}
+	}
+
+	worker := func() {
+		for {
+			mutex.Lock()
+			if first == nil && ctx.Err() != nil {
+				first = ctx.Err()
+			}
+			if first != nil {
+				mutex.Unlock()
+				return
+			}
+			content, ok := it.Next()
+			mutex.Unlock()
+
+			if !ok {
+				return
+			}
+			if err := f(content); err != nil {
+				setFirst(err)
+				return
+			}
+		}
+	}
+
+	if workerCount <= 1 {
+		worker()
+	} else {
+		var wg sync.WaitGroup
+		for i := 0; i < workerCount; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				worker()
+			}()
+		}
+		wg.Wait()
+	}
+
+	err := it.Close()
+	p.fail(err)
+	if first != nil {
+		return first
+	}
+
+	return err
+}
+
+// ForEach is traced and guarded like Map when stream has a dead letter sink, rate limit or breaker
+func (p *pipeline) guardEach(f func(Content)) func(Content) error {
+	if !p.guarded() {
+		return func(c Content) error {
+			f(c)
+			return nil
+		}
+	}
+
+	action := p.tryAction("ForEach", func(c Content) (Content, error) {
+		f(c)
+		return Content{Data: []struct{}{}}, nil
+	}, []struct{}{})
+
+	return func(c Content) error {
+		action(c)
+		return nil
+	}
+}
+
+// ctx given to f is canceled on first error like errgroup, so running calls can stop too
+func (p *pipeline) tryForEach(ctx context.Context, it Iterator, f func(context.Context, Content) error, workerCount int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	st := p.newStage("TryForEach")
+	call := p.broken(st, p.limited(st, func(c Content) (Content, error) {
+		return Content{}, f(ctx, c)
+	}))
+
+	return p.forEach(ctx, it, func(c Content) error {
+		_, err := call(c)
+		if err != nil {
+			cancel()
+		}
+		return err
+	}, workerCount)
+}
@@ -0,0 +1,146 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test ForEach", func() {
+	It("should handle every element", func() {
+		var seen []int
+		Of([]int{1, 2, 3, 4}).Filter(func(content Content) bool {
+			return content.Data.(int) > 1
+		}).ForEach(func(content Content) {
+			seen = append(seen, content.Data.(int))
+		})
+		Expect(seen).To(Equal([]int{2, 3, 4}))
+
+		var keys []string
+		Of(map[string]int{"a": 1, "b": 2}).ForEach(func(content Content) {
+			keys = append(keys, content.Key.(string))
+		})
+		sort.Strings(keys)
+		Expect(keys).To(Equal([]string{"a", "b"}))
+
+		seen = nil
+		Range(0, 3, 1).ForEach(func(content Content) {
+			seen = append(seen, content.Data.(int))
+		})
+		Expect(seen).To(Equal([]int{0, 1, 2}))
+	})
+	It("should handle every element in parallel", func() {
+		var mutex sync.Mutex
+		sum := 0
+		add := func(content Content) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			sum += content.Data.(int)
+		}
+
+		Range(0, 100, 1).ForEachParallel(add, 4)
+		Expect(sum).To(Equal(4950))
+
+		sum = 0
+		Of([]int{1, 2, 3}).ForEachParallel(add, 2)
+		Expect(sum).To(Equal(6))
+
+		sum = 0
+		err := newPipeline().forEach(context.Background(), Range(0, 100, 1).Iterator(), func(content Content) error {
+			add(content)
+			if content.Data.(int) == 50 {
+				return errors.New("fifty")
+			}
+			return nil
+		}, 4)
+		Expect(err).To(MatchError("fifty"))
+		Expect(sum).To(BeNumerically("<", 4950))
+	})
+	It("should guard ForEach like Map", func() {
+		var letters []DeadLetter
+		clock := &fakeClock{}
+		count := 0
+		s := Of([]int{1, 2, 3}).
+			DeadLetter(DeadLetterSlice(&letters)).
+			RateLimit(RateLimit{N: 1, Interval: time.Second, Clock: clock})
+		s.ForEach(func(content Content) {
+			if content.Data.(int) == 2 {
+				panic("two")
+			}
+			count++
+		})
+
+		Expect(count).To(Equal(2))
+		Expect(letters).To(HaveLen(1))
+		Expect(s.Trace()).To(Equal([]StageTrace{{Name: "ForEach 1", DeadLettered: 1, Wait: 2 * time.Second}}))
+	})
+	It("should guard ForEach on workers", func() {
+		var letters []DeadLetter
+		var mutex sync.Mutex
+		sum := 0
+		s := Range(0, 100, 1).
+			DeadLetter(DeadLetterSlice(&letters)).
+			RateLimit(RateLimit{N: 1000, Interval: time.Second, Burst: 100, Clock: &fakeClock{}}).(*lazy)
+		err := s.forEach(context.Background(), s.iterator, s.guardEach(func(content Content) {
+			if content.Data.(int)%10 == 0 {
+				panic("ten")
+			}
+			mutex.Lock()
+			sum += content.Data.(int)
+			mutex.Unlock()
+		}), 4)
+
+		Expect(err).To(BeNil())
+		Expect(sum).To(Equal(4500))
+		Expect(letters).To(HaveLen(10))
+		Expect(s.Trace()[0].DeadLettered).To(Equal(10))
+	})
+	It("should stop on first error", func() {
+		calls := 0
+		var seen context.Context
+		err := Range(0, 100, 1).TryForEach(context.Background(), func(ctx context.Context, content Content) error {
+			calls++
+			seen = ctx
+			if content.Data.(int) == 10 {
+				return errors.New("ten")
+			}
+			return nil
+		})
+		Expect(err).To(MatchError("ten"))
+		Expect(calls).To(Equal(11))
+		Expect(seen.Err()).To(Equal(context.Canceled))
+
+		err = Of([]int{1, 2, 3}).TryForEach(context.Background(), func(ctx context.Context, content Content) error {
+			return nil
+		}, 4)
+		Expect(err).To(BeNil())
+	})
+	It("should stop when context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := Of(map[string]int{"a": 1, "b": 2, "c": 3}).TryForEach(ctx, func(ctx context.Context, content Content) error {
+			calls++
+			cancel()
+			return nil
+		})
+		Expect(err).To(Equal(context.Canceled))
+		Expect(calls).To(Equal(1))
+	})
+	It("should return error of stream", func() {
+		s := OfNDJSON(strings.NewReader("1\n2\n{\n"), 0)
+		count := 0
+		err := s.TryForEach(context.Background(), func(ctx context.Context, content Content) error {
+			count++
+			return nil
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(s.Err()).To(Equal(err))
+		Expect(count).To(Equal(2))
+	})
+})
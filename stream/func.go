@@ -16,3 +16,12 @@ type Compare func(Content, Content) int
 
 // Min Max Function
 type CompareConditional func(Content, Content) bool
+
+// Numeric field extractor
+type Extract func(Content) float64
+
+// Filter that can fail, see TryFilter
+type TryFilter func(Content) (bool, error)
+
+// Action that can fail, see TryMap
+type TryAction func(Content) (Content, error)
@@ -0,0 +1,109 @@
+package stream
+
+import "reflect"
+
+// pulls values from next until it returns false
+type generator struct {
+	next   func() (interface{}, bool)
+	closed bool
+}
+
+func (it *generator) Next() (Content, bool) {
+	if it.closed {
+		return Content{}, false
+	}
+
+	data, ok := it.next()
+	if !ok {
+		it.closed = true
+		return Content{}, false
+	}
+
+	return Content{Data: data}, true
+}
+
+func (it *generator) Close() error {
+	it.closed = true
+	return nil
+}
+
+func generate(format reflect.Type, next func() (interface{}, bool)) IStream {
+	stream := &lazy{
+		pipeline: newPipeline(),
+		iterator: &generator{next: next},
+		kind:     reflect.Slice,
+		format:   format,
+	}
+
+	return stream
+}
+
+// integers from start to end (exclusive) by step, step can be negative
+func Range(start, end, step int) IStream {
+	if step == 0 {
+		panic("step should not be zero")
+	}
+
+	current := start
+	return generate(reflect.TypeOf([]int{}), func() (interface{}, bool) {
+		if (step > 0 && current >= end) || (step < 0 && current <= end) {
+			return nil, false
+		}
+		current += step
+
+		return current - step, true
+	})
+}
+
+// infinite stream of seed, next(seed), next(next(seed)), ...
+// next should be a func that takes and returns seed type
+func Iterate(seed interface{}, next interface{}) IStream {
+	f := reflect.ValueOf(next)
+	t := reflect.TypeOf(seed)
+	if f.Kind() != reflect.Func || f.Type().NumIn() != 1 || f.Type().NumOut() != 1 ||
+		!t.AssignableTo(f.Type().In(0)) || !f.Type().Out(0).AssignableTo(t) {
+		panic("next should be a func that takes and returns seed type")
+	}
+
+	current := reflect.ValueOf(seed)
+	started := false
+	return generate(reflect.SliceOf(t), func() (interface{}, bool) {
+		if started {
+			current = f.Call([]reflect.Value{current})[0]
+		}
+		started = true
+
+		return current.Interface(), true
+	})
+}
+
+// infinite stream of supplier results
+// supplier should be a func without argument that returns one value, its type is the element type
+func Generate(supplier interface{}) IStream {
+	f := reflect.ValueOf(supplier)
+	if f.Kind() != reflect.Func || f.Type().NumIn() != 0 || f.Type().NumOut() != 1 {
+		panic("supplier should be a func without argument that returns one value")
+	}
+
+	return generate(reflect.SliceOf(f.Type().Out(0)), func() (interface{}, bool) {
+		return f.Call(nil)[0].Interface(), true
+	})
+}
+
+// value n times, negative n repeats forever
+func Repeat(value interface{}, n int) IStream {
+	format := reflect.TypeOf([]interface{}{})
+	if value != nil {
+		format = reflect.SliceOf(reflect.TypeOf(value))
+	}
+
+	count := 0
+	return generate(format, func() (interface{}, bool) {
+		if n >= 0 && count >= n {
+			return nil, false
+		}
+		count++
+
+		return value, true
+	})
+}
@@ -0,0 +1,87 @@
+package stream
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Generator", func() {
+	Describe("Range", func() {
+		It("should generate integers", func() {
+			Expect(Range(0, 5, 1).Interface()).To(Equal([]int{0, 1, 2, 3, 4}))
+			Expect(Range(0, 10, 3).Interface()).To(Equal([]int{0, 3, 6, 9}))
+			Expect(Range(5, 0, -2).Interface()).To(Equal([]int{5, 3, 1}))
+			Expect(Range(5, 5, 1).Count()).To(Equal(0))
+		})
+		It("should panic when step is zero", func() {
+			Expect(func() { Range(0, 1, 0) }).To(Panic())
+		})
+		It("should filter and map", func() {
+			v := Range(1, 100, 1).
+				Filter(func(content Content) bool {
+					return content.Data.(int)%10 == 0
+				}).
+				Map(func(content Content) Content {
+					return Content{Data: content.Data.(int) / 10}
+				}, []int{}).
+				Interface()
+			Expect(v).To(Equal([]int{1, 2, 3, 4, 5, 6, 7, 8, 9}))
+		})
+	})
+	Describe("Iterate", func() {
+		It("should generate until limit", func() {
+			v := Iterate(1, func(i int) int { return i * 2 }).Limit(5).Interface()
+			Expect(v).To(Equal([]int{1, 2, 4, 8, 16}))
+		})
+		It("should generate until take while fails", func() {
+			v := Iterate("a", func(s string) string { return s + "a" }).
+				TakeWhile(func(content Content) bool {
+					return len(content.Data.(string)) < 4
+				}).
+				Interface()
+			Expect(v).To(Equal([]string{"a", "aa", "aaa"}))
+		})
+		It("should panic when next does not match seed", func() {
+			Expect(func() { Iterate(1, func(s string) string { return s }) }).To(Panic())
+			Expect(func() { Iterate(1, 2) }).To(Panic())
+		})
+	})
+	Describe("Generate", func() {
+		It("should call supplier only for pulled elements", func() {
+			calls := 0
+			v := Generate(func() int {
+				calls++
+				return calls
+			}).Skip(2).Limit(3).Interface()
+			Expect(v).To(Equal([]int{3, 4, 5}))
+			Expect(calls).To(Equal(5))
+		})
+		It("should panic when supplier is not a func", func() {
+			Expect(func() { Generate(1) }).To(Panic())
+			Expect(func() { Generate(func(int) int { return 1 }) }).To(Panic())
+		})
+	})
+	Describe("Repeat", func() {
+		It("should repeat n times", func() {
+			Expect(Repeat("x", 3).Interface()).To(Equal([]string{"x", "x", "x"}))
+			Expect(Repeat(nil, 2).Interface()).To(Equal([]interface{}{nil, nil}))
+		})
+		It("should repeat forever when n is negative", func() {
+			Expect(Repeat(1, -1).Limit(1000).Count()).To(Equal(1000))
+		})
+	})
+	Describe("TakeWhile", func() {
+		It("should take list elements", func() {
+			v := Of([]int{1, 2, 3, 1}).TakeWhile(func(content Content) bool {
+				return content.Data.(int) < 3
+			}).Interface()
+			Expect(v).To(Equal([]int{1, 2}))
+		})
+		It("should take map entries", func() {
+			v := Of(map[string]int{"a": 1, "b": 2}).TakeWhile(func(content Content) bool {
+				return content.Key.(string) != ""
+			}).Interface()
+			Expect(v).To(Equal(map[string]int{"a": 1, "b": 2}))
+		})
+	})
+})
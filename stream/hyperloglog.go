@@ -0,0 +1,91 @@
+package stream
+
+import (
+	"math"
+	"math/bits"
+)
+
+// HyperLogLog distinct counter with 2^precision registers
+// standard error is about 1.04 / sqrt(2^precision)
+type hyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+func newHyperLogLog(precision uint8) *hyperLogLog {
+	return &hyperLogLog{
+		precision: precision,
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// smallest precision that satisfies relative error, in [4, 18]
+func hyperLogLogPrecision(relativeError float64) uint8 {
+	if relativeError <= 0 {
+		relativeError = 0.01
+	}
+
+	p := math.Ceil(math.Log2(math.Pow(1.04/relativeError, 2)))
+	if p < 4 {
+		return 4
+	}
+	if p > 18 {
+		return 18
+	}
+
+	return uint8(p)
+}
+
+func (h *hyperLogLog) add(hash uint64) {
+	index := hash >> (64 - h.precision)
+	// keep a sentinel bit so rank is bounded
+	w := hash<<h.precision | 1<<(h.precision-1)
+	rank := uint8(bits.LeadingZeros64(w)) + 1
+
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// sketches should have same precision
+func (h *hyperLogLog) merge(o *hyperLogLog) {
+	for i, r := range o.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+}
+
+func (h *hyperLogLog) count() int {
+	m := float64(len(h.registers))
+
+	var alpha float64
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha * m * m / sum
+
+	// small range correction
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return int(estimate + 0.5)
+}
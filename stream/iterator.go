@@ -0,0 +1,354 @@
+package stream
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Iterator pulls elements one by one.
+// Next returns false when there is no element left, Close releases the source
+// and it is safe to call more than once
+type Iterator interface {
+	Next() (Content, bool)
+	Close() error
+}
+
+// iterates slice or array items in order
+type sliceIterator struct {
+	items reflect.Value
+	index int
+}
+
+func (it *sliceIterator) Next() (Content, bool) {
+	if it.index >= it.items.Len() {
+		return Content{}, false
+	}
+	it.index++
+
+	return Content{Data: it.items.Index(it.index - 1).Interface()}, true
+}
+
+func (it *sliceIterator) Close() error {
+	it.index = it.items.Len()
+	return nil
+}
+
+// iterates map entries, order changes in run time
+type entryIterator struct {
+	items reflect.Value
+	keys  []reflect.Value
+	index int
+}
+
+func (it *entryIterator) Next() (Content, bool) {
+	if it.index >= len(it.keys) {
+		return Content{}, false
+	}
+	key := it.keys[it.index]
+	it.index++
+
+	return Content{Key: key.Interface(), Data: it.items.MapIndex(key).Interface()}, true
+}
+
+func (it *entryIterator) Close() error {
+	it.index = len(it.keys)
+	return nil
+}
+
+// receives from channel until it is closed, channel is not read after Close
+type chanIterator struct {
+	ch     reflect.Value
+	closed bool
+}
+
+func (it *chanIterator) Next() (Content, bool) {
+	if it.closed {
+		return Content{}, false
+	}
+
+	item, ok := it.ch.Recv()
+	if !ok {
+		it.closed = true
+		return Content{}, false
+	}
+
+	return Content{Data: item.Interface()}, true
+}
+
+func (it *chanIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+type filterIterator struct {
+	source Iterator
+	f      Filter
+}
+
+func (it *filterIterator) Next() (Content, bool) {
+	for {
+		c, ok := it.source.Next()
+		if !ok || it.f(c) {
+			return c, ok
+		}
+	}
+}
+
+func (it *filterIterator) Close() error {
+	return it.source.Close()
+}
+
+// applies action and flattens slice or map results for new type
+type actionIterator struct {
+	source  Iterator
+	f       Action
+	format  reflect.Type
+	pending []Content
+}
+
+func (it *actionIterator) Next() (Content, bool) {
+	for len(it.pending) == 0 {
+		c, ok := it.source.Next()
+		if !ok {
+			return c, false
+		}
+		it.pending = flatten(it.f(c), it.format)
+	}
+
+	c := it.pending[0]
+	it.pending = it.pending[1:]
+
+	return c, true
+}
+
+func (it *actionIterator) Close() error {
+	it.pending = nil
+	return it.source.Close()
+}
+
+// slice results are spread into slice streams and map results into map streams
+func flatten(c Content, format reflect.Type) []Content {
+	v := reflect.ValueOf(c.Data)
+	kind := v.Kind()
+
+	if format.Kind() == reflect.Map {
+		if kind != reflect.Map {
+			return []Content{c}
+		}
+
+		var contents []Content
+		for _, k := range v.MapKeys() {
+			contents = append(contents, Content{Key: k.Interface(), Data: v.MapIndex(k).Interface()})
+		}
+		return contents
+	}
+
+	if kind != reflect.Slice && kind != reflect.Array {
+		return []Content{c}
+	}
+
+	contents := make([]Content, v.Len())
+	for i := range contents {
+		contents[i] = Content{Data: v.Index(i).Interface()}
+	}
+	return contents
+}
+
+type skipIterator struct {
+	source  Iterator
+	skip    int
+	skipped int
+}
+
+func (it *skipIterator) Next() (Content, bool) {
+	for ; it.skipped < it.skip; it.skipped++ {
+		if _, ok := it.source.Next(); !ok {
+			return Content{}, false
+		}
+	}
+
+	return it.source.Next()
+}
+
+func (it *skipIterator) Close() error {
+	return it.source.Close()
+}
+
+// source is closed as soon as limit is reached, so it is not pulled any more
+type limitIterator struct {
+	source Iterator
+	limit  int
+	read   int
+}
+
+func (it *limitIterator) Next() (Content, bool) {
+	if it.read >= it.limit {
+		return Content{}, false
+	}
+
+	c, ok := it.source.Next()
+	if !ok {
+		return c, false
+	}
+
+	it.read++
+	if it.read == it.limit {
+		it.source.Close()
+	}
+
+	return c, true
+}
+
+func (it *limitIterator) Close() error {
+	it.read = it.limit
+	return it.source.Close()
+}
+
+// source is closed at first element that does not match
+type takeWhileIterator struct {
+	source Iterator
+	f      Filter
+	done   bool
+}
+
+func (it *takeWhileIterator) Next() (Content, bool) {
+	if it.done {
+		return Content{}, false
+	}
+
+	c, ok := it.source.Next()
+	if !ok || !it.f(c) {
+		it.done = true
+		it.source.Close()
+		return Content{}, false
+	}
+
+	return c, true
+}
+
+func (it *takeWhileIterator) Close() error {
+	it.done = true
+	return it.source.Close()
+}
+
+// workers pull source one by one and apply stage, results come in arrival order
+type parallelIterator struct {
+	source      Iterator
+	stage       func(Content) []Content
+	workerCount int
+	once        sync.Once
+	results     chan Content
+	done        chan struct{}
+	finished    chan struct{}
+	wg          sync.WaitGroup
+	mutex       sync.Mutex
+	closeOnce   sync.Once
+}
+
+func newParallelIterator(source Iterator, workerCount int, stage func(Content) []Content) *parallelIterator {
+	return &parallelIterator{
+		source:      source,
+		stage:       stage,
+		workerCount: workerCount,
+		results:     make(chan Content, workerCount),
+		done:        make(chan struct{}),
+		finished:    make(chan struct{}),
+	}
+}
+
+func (it *parallelIterator) start() {
+	for i := 0; i < it.workerCount; i++ {
+		it.wg.Add(1)
+		go func() {
+			defer it.wg.Done()
+			for {
+				select {
+				case <-it.done:
+					return
+				default:
+				}
+
+				it.mutex.Lock()
+				c, ok := it.source.Next()
+				it.mutex.Unlock()
+
+				if !ok {
+					return
+				}
+
+				for _, result := range it.stage(c) {
+					select {
+					case it.results <- result:
+					case <-it.done:
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	// finished is closed first, so Close after last result closes source itself and returns its error
+	go func() {
+		it.wg.Wait()
+		close(it.finished)
+		close(it.results)
+	}()
+}
+
+func (it *parallelIterator) Next() (Content, bool) {
+	it.once.Do(it.start)
+
+	select {
+	case c, ok := <-it.results:
+		return c, ok
+	case <-it.done:
+		return Content{}, false
+	}
+}
+
+// stops workers. when workers are still running source is closed after last of them returns
+// since a worker may wait on it, otherwise source is closed and its error is returned
+func (it *parallelIterator) Close() error {
+	var err error
+	it.closeOnce.Do(func() {
+		close(it.done)
+
+		// never started
+		it.once.Do(func() { close(it.finished) })
+
+		if isClosed(it.finished) {
+			err = it.source.Close()
+			return
+		}
+
+		go func() {
+			<-it.finished
+
+			it.mutex.Lock()
+			it.source.Close()
+			it.mutex.Unlock()
+		}()
+	})
+
+	return err
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// calls f for every element until it returns false, then closes iterator and returns its error
+func drain(it Iterator, f func(Content) bool) error {
+	for c, ok := it.Next(); ok; c, ok = it.Next() {
+		if !f(c) {
+			break
+		}
+	}
+
+	return it.Close()
+}
@@ -0,0 +1,130 @@
+package stream
+
+import (
+	"sort"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// counts from one forever
+type countingIterator struct {
+	mutex  sync.Mutex
+	pulled int
+	closed bool
+}
+
+func (it *countingIterator) Next() (Content, bool) {
+	it.mutex.Lock()
+	defer it.mutex.Unlock()
+
+	if it.closed {
+		return Content{}, false
+	}
+	it.pulled++
+
+	return Content{Data: it.pulled}, true
+}
+
+func (it *countingIterator) Close() error {
+	it.mutex.Lock()
+	defer it.mutex.Unlock()
+
+	it.closed = true
+	return nil
+}
+
+func (it *countingIterator) isClosed() bool {
+	it.mutex.Lock()
+	defer it.mutex.Unlock()
+
+	return it.closed
+}
+
+var _ = Describe("Test Iterator", func() {
+	Describe("OfIterator", func() {
+		It("should panic when new type is not slice or map", func() {
+			Expect(func() { OfIterator(&countingIterator{}, 1) }).To(Panic())
+		})
+		It("should stop pulling infinite source at limit", func() {
+			source := &countingIterator{}
+			v := OfIterator(source, []int{}).
+				Filter(func(content Content) bool {
+					return content.Data.(int)%2 == 0
+				}).
+				Map(func(content Content) Content {
+					return Content{Data: content.Data.(int) * 10}
+				}, []int{}).
+				Limit(3).
+				Interface()
+			Expect(v).To(Equal([]int{20, 40, 60}))
+			Expect(source.pulled).To(Equal(6))
+			Expect(source.closed).To(BeTrue())
+		})
+		It("should close source after first match", func() {
+			source := &countingIterator{}
+			res := OfIterator(source, []int{}).AnyMatch(func(content Content) bool {
+				return content.Data.(int) == 4
+			})
+			Expect(res).To(BeTrue())
+			Expect(source.pulled).To(Equal(4))
+			Expect(source.closed).To(BeTrue())
+		})
+		It("should compose iterator of another stream", func() {
+			v := OfIterator(Of([]int{1, 2, 3}).Skip(1).Iterator(), []int{}).Skip(1).Interface()
+			Expect(v).To(Equal([]int{3}))
+		})
+	})
+	Describe("Iterator", func() {
+		It("should iterate list", func() {
+			it := Of([]int{1, 2, 3}).Filter(func(content Content) bool {
+				return content.Data.(int) > 1
+			}).Iterator()
+			c, ok := it.Next()
+			Expect(ok).To(BeTrue())
+			Expect(c.Data).To(Equal(2))
+			Expect(it.Close()).To(Succeed())
+			_, ok = it.Next()
+			Expect(ok).To(BeFalse())
+		})
+		It("should iterate map with keys", func() {
+			res := map[interface{}]interface{}{}
+			drain(Of(map[string]int{"a": 1, "b": 2}).Iterator(), func(c Content) bool {
+				res[c.Key] = c.Data
+				return true
+			})
+			Expect(res).To(Equal(map[interface{}]interface{}{"a": 1, "b": 2}))
+		})
+	})
+	Describe("parallelIterator", func() {
+		It("should apply stage with workers", func() {
+			it := newParallelIterator(Of([]int{1, 2, 3, 4, 5, 6}).Iterator(), 4, func(c Content) []Content {
+				return []Content{c, c}
+			})
+			var res []int
+			drain(it, func(c Content) bool {
+				res = append(res, c.Data.(int))
+				return true
+			})
+			sort.Ints(res)
+			Expect(res).To(Equal([]int{1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6}))
+		})
+		It("should stop workers and close source on limit", func() {
+			source := &countingIterator{}
+			it := &limitIterator{
+				source: newParallelIterator(source, 4, func(c Content) []Content {
+					return []Content{c}
+				}),
+				limit: 5,
+			}
+			count := 0
+			drain(it, func(Content) bool {
+				count++
+				return true
+			})
+			Expect(count).To(Equal(5))
+			Eventually(source.isClosed).Should(BeTrue())
+		})
+	})
+})
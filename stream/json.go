@@ -0,0 +1,182 @@
+package stream
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// stream of top level json array elements, elements are decoded one by one as they are pulled.
+// elemType is a sample of element type, decode errors are set to Err of stream
+func OfJSONArray(r io.Reader, elemType interface{}) IStream {
+	typeOf := reflect.TypeOf(elemType)
+
+	return OfIterator(&jsonIterator{decoder: json.NewDecoder(r), format: typeOf, array: true},
+		reflect.MakeSlice(reflect.SliceOf(typeOf), 0, 0).Interface())
+}
+
+// stream of newline delimited json values
+func OfNDJSON(r io.Reader, elemType interface{}) IStream {
+	typeOf := reflect.TypeOf(elemType)
+
+	return OfIterator(&jsonIterator{decoder: json.NewDecoder(r), format: typeOf},
+		reflect.MakeSlice(reflect.SliceOf(typeOf), 0, 0).Interface())
+}
+
+type jsonIterator struct {
+	decoder *json.Decoder
+	format  reflect.Type
+	array   bool
+	started bool
+	done    bool
+	err     error
+}
+
+func (it *jsonIterator) Next() (Content, bool) {
+	if it.done {
+		return Content{}, false
+	}
+
+	if it.array && !it.started {
+		it.started = true
+		if err := it.delim('['); err != nil {
+			return it.fail(err)
+		}
+	}
+
+	if it.array && !it.decoder.More() {
+		return it.fail(it.delim(']'))
+	}
+
+	item := reflect.New(it.format)
+	if err := it.decoder.Decode(item.Interface()); err != nil {
+		if err == io.EOF && !it.array {
+			err = nil
+		}
+		return it.fail(err)
+	}
+
+	return Content{Data: item.Elem().Interface()}, true
+}
+
+func (it *jsonIterator) delim(d json.Delim) error {
+	token, err := it.decoder.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	if token != d {
+		return fmt.Errorf("expected %v but found %v", d, token)
+	}
+
+	return nil
+}
+
+func (it *jsonIterator) fail(err error) (Content, bool) {
+	it.done = true
+	it.err = err
+
+	return Content{}, false
+}
+
+func (it *jsonIterator) Close() error {
+	it.done = true
+	return it.err
+}
+
+// elements are encoded as they are pulled. first of encode, write and source errors is returned
+func (p *pipeline) writeJSON(w io.Writer, it Iterator, object, lines bool) error {
+	writer := bufio.NewWriter(w)
+	openDelim, closeDelim := "[", "]"
+	if object {
+		openDelim, closeDelim = "{", "}"
+	}
+
+	var err error
+	if !lines {
+		_, err = writer.WriteString(openDelim)
+	}
+
+	first := true
+	closeErr := drain(it, func(c Content) bool {
+		if err != nil {
+			return false
+		}
+
+		var b []byte
+		if b, err = encodeJSON(c, object); err != nil {
+			return false
+		}
+
+		if lines {
+			if object {
+				b = append(append([]byte("{"), b...), '}')
+			}
+			b = append(b, '\n')
+		} else if !first {
+			b = append([]byte(","), b...)
+		}
+		first = false
+
+		_, err = writer.Write(b)
+		return err == nil
+	})
+
+	if err == nil && !lines {
+		_, err = writer.WriteString(closeDelim)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = closeErr
+	}
+
+	p.fail(err)
+
+	return err
+}
+
+// object entries are encoded as "key":value
+func encodeJSON(c Content, object bool) ([]byte, error) {
+	value, err := json.Marshal(c.Data)
+	if err != nil || !object {
+		return value, err
+	}
+
+	key, err := jsonKey(c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(key, ':'), value...), nil
+}
+
+// keys are encoded like encoding/json does for maps
+func jsonKey(key interface{}) ([]byte, error) {
+	if m, ok := key.(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(string(text))
+	}
+
+	v := reflect.ValueOf(key)
+	switch v.Kind() {
+	case reflect.String:
+		return json.Marshal(v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Marshal(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return json.Marshal(strconv.FormatUint(v.Uint(), 10))
+	}
+
+	return nil, fmt.Errorf("unsupported json key type %T", key)
+}
@@ -0,0 +1,136 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type jsonPerson struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+var _ = Describe("Test JSON", func() {
+	Describe("OfJSONArray", func() {
+		It("should decode elements", func() {
+			data := `[{"name":"ali","age":30}, {"name":"veli","age":20}]`
+			v := OfJSONArray(strings.NewReader(data), jsonPerson{}).Interface()
+			Expect(v).To(Equal([]jsonPerson{{Name: "ali", Age: 30}, {Name: "veli", Age: 20}}))
+		})
+		It("should decode lazily", func() {
+			data := `[1, 2, 3, "not a number"]`
+			s := OfJSONArray(strings.NewReader(data), 0).Limit(2)
+			Expect(s.Interface()).To(Equal([]int{1, 2}))
+			Expect(s.Err()).To(BeNil())
+		})
+		It("should decode empty array", func() {
+			s := OfJSONArray(strings.NewReader(` [ ] `), "")
+			Expect(s.Count()).To(Equal(0))
+			Expect(s.Err()).To(BeNil())
+		})
+		It("should set error when input is not array", func() {
+			s := OfJSONArray(strings.NewReader(`{"a":1}`), 0)
+			Expect(s.Count()).To(Equal(0))
+			Expect(s.Err()).To(HaveOccurred())
+		})
+		It("should set error of truncated array", func() {
+			s := OfJSONArray(strings.NewReader(`[1, 2`), 0)
+			Expect(s.Interface()).To(Equal([]int{1, 2}))
+			Expect(s.Err()).To(HaveOccurred())
+		})
+		It("should set decode error", func() {
+			s := OfJSONArray(strings.NewReader(`[1, "a", 3]`), 0)
+			Expect(s.Interface()).To(Equal([]int{1}))
+			Expect(s.Err()).To(HaveOccurred())
+		})
+	})
+	Describe("OfNDJSON", func() {
+		It("should decode lines", func() {
+			data := "{\"name\":\"ali\",\"age\":30}\n{\"name\":\"veli\",\"age\":20}\n"
+			v := OfNDJSON(strings.NewReader(data), jsonPerson{}).
+				Filter(func(content Content) bool {
+					return content.Data.(jsonPerson).Age > 25
+				}).
+				Interface()
+			Expect(v).To(Equal([]jsonPerson{{Name: "ali", Age: 30}}))
+		})
+		It("should set decode error", func() {
+			s := OfNDJSON(strings.NewReader("1\n2\n{\n"), 0)
+			Expect(s.Interface()).To(Equal([]int{1, 2}))
+			Expect(s.Err()).To(HaveOccurred())
+		})
+	})
+	Describe("WriteJSON", func() {
+		It("should write list as array", func() {
+			var buf bytes.Buffer
+			err := Of([]jsonPerson{{Name: "ali", Age: 30}, {Name: "veli", Age: 20}}).
+				Limit(1).
+				WriteJSON(&buf)
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal(`[{"name":"ali","age":30}]`))
+		})
+		It("should write empty array", func() {
+			var buf bytes.Buffer
+			Expect(Of([]int{}).WriteJSON(&buf)).To(BeNil())
+			Expect(buf.String()).To(Equal(`[]`))
+		})
+		It("should write map as object", func() {
+			var buf bytes.Buffer
+			Expect(Of(map[int]string{1: "a", 2: "b"}).WriteJSON(&buf)).To(BeNil())
+
+			var v map[string]string
+			Expect(json.Unmarshal(buf.Bytes(), &v)).To(BeNil())
+			Expect(v).To(Equal(map[string]string{"1": "a", "2": "b"}))
+		})
+		It("should transform json array", func() {
+			var buf bytes.Buffer
+			err := OfJSONArray(strings.NewReader(`[1,2,3,4]`), 0).
+				Map(func(content Content) Content {
+					return Content{Data: content.Data.(int) * 10}
+				}, []int{}).
+				WriteJSON(&buf)
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal(`[10,20,30,40]`))
+		})
+		It("should return source error", func() {
+			var buf bytes.Buffer
+			s := OfJSONArray(strings.NewReader(`[1,2`), 0)
+			Expect(s.WriteJSON(&buf)).To(HaveOccurred())
+			Expect(s.Err()).To(HaveOccurred())
+		})
+		It("should return write error", func() {
+			Expect(Of([]int{1}).WriteJSON(failingWriter{})).To(MatchError("write failed"))
+		})
+		It("should return encode error", func() {
+			Expect(Of([]interface{}{1, make(chan int)}).WriteJSON(&bytes.Buffer{})).To(HaveOccurred())
+		})
+	})
+	Describe("WriteNDJSON", func() {
+		It("should write one element per line", func() {
+			var buf bytes.Buffer
+			Expect(Of([]int{1, 2, 3}).WriteNDJSON(&buf)).To(BeNil())
+			Expect(buf.String()).To(Equal("1\n2\n3\n"))
+		})
+		It("should write map entries as objects", func() {
+			var buf bytes.Buffer
+			Expect(Of(map[string]int{"a": 1}).WriteNDJSON(&buf)).To(BeNil())
+			Expect(buf.String()).To(Equal("{\"a\":1}\n"))
+		})
+		It("should convert ndjson to array", func() {
+			var buf bytes.Buffer
+			Expect(OfNDJSON(strings.NewReader("\"a\"\n\"b\"\n"), "").WriteJSON(&buf)).To(BeNil())
+			Expect(buf.String()).To(Equal(`["a","b"]`))
+		})
+	})
+})
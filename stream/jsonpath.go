@@ -0,0 +1,658 @@
+package stream
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// stream of values in doc selected by JSONPath like "$.orders[*].items[?(@.qty>0)].price".
+// supported subset: .name ['name'] .* [*] ..name ..* [0] [-1] [0,2] [start:end:step] and
+// filters [?(@.a.b > 1 && @.c == 'x' || !@.d)] comparing paths with numbers, strings, true, false and null.
+// maps with string keys, slices and struct fields are walked, map keys in sorted order.
+// invalid path is set to Err of an empty stream
+func OfPath(doc interface{}, path string) IStream {
+	matches := []interface{}{}
+
+	steps, err := parsePath(path)
+	if err == nil {
+		root := reflect.ValueOf(doc)
+		for _, v := range selectPath(steps, root, root) {
+			matches = append(matches, valueOf(v))
+		}
+	}
+
+	stream := Of(matches).(*lazy)
+	stream.fail(err)
+
+	return stream
+}
+
+type stepKind int
+
+const (
+	childStep stepKind = iota
+	wildcardStep
+	sliceStep
+	filterStep
+)
+
+type pathStep struct {
+	kind      stepKind
+	recursive bool
+	// names and indices of child step, string or int
+	keys []interface{}
+	// slice bounds, nil is open
+	start, end *int
+	step       int
+	filter     pathExpr
+}
+
+func selectPath(steps []pathStep, v reflect.Value, root reflect.Value) []reflect.Value {
+	nodes := []reflect.Value{v}
+	for _, step := range steps {
+		var next []reflect.Value
+		emit := func(v reflect.Value) {
+			next = append(next, v)
+		}
+		for _, node := range nodes {
+			if step.recursive {
+				walk(node, func(v reflect.Value) {
+					step.apply(v, root, emit)
+				})
+			} else {
+				step.apply(node, root, emit)
+			}
+		}
+		nodes = next
+	}
+
+	return nodes
+}
+
+func (s pathStep) apply(v reflect.Value, root reflect.Value, emit func(reflect.Value)) {
+	v, ok := indirect(v)
+	if !ok {
+		return
+	}
+
+	switch s.kind {
+	case childStep:
+		for _, key := range s.keys {
+			if child, ok := childOf(v, key); ok {
+				emit(child)
+			}
+		}
+	case wildcardStep:
+		for _, child := range children(v) {
+			emit(child)
+		}
+	case sliceStep:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return
+		}
+		for _, i := range sliceIndices(v.Len(), s.start, s.end, s.step) {
+			emit(v.Index(i))
+		}
+	case filterStep:
+		for _, child := range children(v) {
+			if truth(s.filter.eval(child, root)) {
+				emit(child)
+			}
+		}
+	}
+}
+
+// node and all its descendants in document order
+func walk(v reflect.Value, f func(reflect.Value)) {
+	f(v)
+
+	v, ok := indirect(v)
+	if !ok {
+		return
+	}
+	for _, child := range children(v) {
+		walk(child, f)
+	}
+}
+
+// interface and pointer values are resolved, false for nil
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+
+	return v, v.IsValid()
+}
+
+func valueOf(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+func children(v reflect.Value) []reflect.Value {
+	var values []reflect.Value
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, v.Index(i))
+		}
+	case reflect.Map:
+		for _, key := range sortedMapKeys(v) {
+			values = append(values, v.MapIndex(key))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				values = append(values, v.Field(i))
+			}
+		}
+	}
+
+	return values
+}
+
+// string key selects map entries and struct fields, int key selects slice elements, negative from end
+func childOf(v reflect.Value, key interface{}) (reflect.Value, bool) {
+	switch key := key.(type) {
+	case string:
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false
+			}
+			child := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+			return child, child.IsValid()
+		case reflect.Struct:
+			field, ok := v.Type().FieldByName(key)
+			if !ok || field.PkgPath != "" {
+				return reflect.Value{}, false
+			}
+			return v.FieldByIndex(field.Index), true
+		}
+	case int:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return reflect.Value{}, false
+		}
+		if key < 0 {
+			key += v.Len()
+		}
+		if key < 0 || key >= v.Len() {
+			return reflect.Value{}, false
+		}
+		return v.Index(key), true
+	}
+
+	return reflect.Value{}, false
+}
+
+// python like slice indices
+func sliceIndices(length int, start, end *int, step int) []int {
+	if step == 0 {
+		return nil
+	}
+
+	bound := func(i *int, def int) int {
+		if i == nil {
+			return def
+		}
+		v := *i
+		if v < 0 {
+			v += length
+		}
+		if step > 0 {
+			return clamp(v, 0, length)
+		}
+		return clamp(v, -1, length-1)
+	}
+
+	var indices []int
+	if step > 0 {
+		for i := bound(start, 0); i < bound(end, length); i += step {
+			indices = append(indices, i)
+		}
+	} else {
+		for i := bound(start, length-1); i > bound(end, -1); i += step {
+			indices = append(indices, i)
+		}
+	}
+
+	return indices
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+
+	return v
+}
+
+// filter expression, eval returns value and whether it exists
+type pathExpr interface {
+	eval(current, root reflect.Value) (interface{}, bool)
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(_, _ reflect.Value) (interface{}, bool) {
+	return e.value, true
+}
+
+// @ or $ relative path, first match is its value
+type nodeExpr struct {
+	root  bool
+	steps []pathStep
+}
+
+func (e nodeExpr) eval(current, root reflect.Value) (interface{}, bool) {
+	start := current
+	if e.root {
+		start = root
+	}
+
+	matches := selectPath(e.steps, start, root)
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	return valueOf(matches[0]), true
+}
+
+type notExpr struct {
+	x pathExpr
+}
+
+func (e notExpr) eval(current, root reflect.Value) (interface{}, bool) {
+	return !truth(e.x.eval(current, root)), true
+}
+
+type binaryExpr struct {
+	op          string
+	left, right pathExpr
+}
+
+func (e binaryExpr) eval(current, root reflect.Value) (interface{}, bool) {
+	switch e.op {
+	case "&&":
+		return truth(e.left.eval(current, root)) && truth(e.right.eval(current, root)), true
+	case "||":
+		return truth(e.left.eval(current, root)) || truth(e.right.eval(current, root)), true
+	}
+
+	l, lok := e.left.eval(current, root)
+	r, rok := e.right.eval(current, root)
+	if !lok || !rok {
+		return false, true
+	}
+
+	return compareValues(e.op, l, r), true
+}
+
+// existing values other than false are true
+func truth(v interface{}, ok bool) bool {
+	return ok && v != false
+}
+
+// numbers are compared as float, strings lexically, others only by equality
+func compareValues(op string, l, r interface{}) bool {
+	var c int
+	lf, lnum := toNumber(l)
+	rf, rnum := toNumber(r)
+	ls, lstr := l.(string)
+	rs, rstr := r.(string)
+
+	switch {
+	case lnum && rnum:
+		c = compareFloat(lf, rf)
+	case lstr && rstr:
+		c = strings.Compare(ls, rs)
+	case op == "==":
+		return reflect.DeepEqual(l, r)
+	case op == "!=":
+		return !reflect.DeepEqual(l, r)
+	default:
+		return false
+	}
+
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func compareFloat(a, b float64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+
+	return 0
+}
+
+// paths are lexed by expression lexer, so quoting and escapes of names and strings are same with expressions
+var pathOperators = append([]string{"..", "$", "@", "?", ":"}, exprOperators...)
+
+type pathParser struct {
+	exprParser
+}
+
+func parsePath(path string) ([]pathStep, error) {
+	p := &pathParser{exprParser{src: strings.TrimSpace(path), operators: pathOperators}}
+	if err := p.lex(); err != nil {
+		exprErr := err.(*ExprError)
+		return nil, p.errorf(exprErr.Pos, "%s", exprErr.Msg)
+	}
+	p.consume("$")
+
+	steps, err := p.steps()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != eofToken {
+		return nil, p.errorf(t.pos, "unexpected %q", t.text)
+	}
+
+	return steps, nil
+}
+
+func (p *pathParser) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("jsonpath %q: %s at position %d", p.src, fmt.Sprintf(format, args...), pos)
+}
+
+func (p *pathParser) is(op string) bool {
+	t := p.peek()
+	return t.kind == operatorToken && t.text == op
+}
+
+func (p *pathParser) consume(op string) bool {
+	if p.is(op) {
+		p.index++
+		return true
+	}
+
+	return false
+}
+
+func (p *pathParser) expect(op string) error {
+	if !p.consume(op) {
+		return p.errorf(p.peek().pos, "expected %s", op)
+	}
+
+	return nil
+}
+
+func (p *pathParser) steps() ([]pathStep, error) {
+	var steps []pathStep
+	for {
+		var step pathStep
+		var err error
+
+		switch {
+		case p.consume(".."):
+			if p.is("[") {
+				step, err = p.bracket()
+			} else {
+				step, err = p.dotted()
+			}
+			step.recursive = true
+		case p.consume("."):
+			step, err = p.dotted()
+		case p.is("["):
+			step, err = p.bracket()
+		default:
+			return steps, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+}
+
+func (p *pathParser) dotted() (pathStep, error) {
+	if p.consume("*") {
+		return pathStep{kind: wildcardStep}, nil
+	}
+
+	t := p.peek()
+	if t.kind != identToken {
+		return pathStep{}, p.errorf(t.pos, "expected name")
+	}
+	p.index++
+
+	return pathStep{kind: childStep, keys: []interface{}{t.text}}, nil
+}
+
+func (p *pathParser) bracket() (pathStep, error) {
+	p.consume("[")
+
+	var step pathStep
+	switch {
+	case p.consume("*"):
+		step = pathStep{kind: wildcardStep}
+	case p.consume("?"):
+		if err := p.expect("("); err != nil {
+			return step, err
+		}
+		filter, err := p.or()
+		if err != nil {
+			return step, err
+		}
+		if err := p.expect(")"); err != nil {
+			return step, err
+		}
+		step = pathStep{kind: filterStep, filter: filter}
+	default:
+		var err error
+		if step, err = p.keys(); err != nil {
+			return step, err
+		}
+	}
+
+	return step, p.expect("]")
+}
+
+// union of names and indices or a slice
+func (p *pathParser) keys() (pathStep, error) {
+	step := pathStep{kind: childStep}
+	for {
+		if t := p.peek(); t.kind == stringToken {
+			p.index++
+			step.keys = append(step.keys, t.value)
+		} else {
+			i, ok := p.int()
+			if p.is(":") && len(step.keys) == 0 {
+				return p.slice(i, ok)
+			}
+			if !ok {
+				return step, p.errorf(t.pos, "expected index or name")
+			}
+			step.keys = append(step.keys, i)
+		}
+
+		if !p.consume(",") {
+			return step, nil
+		}
+	}
+}
+
+func (p *pathParser) slice(start int, hasStart bool) (pathStep, error) {
+	step := pathStep{kind: sliceStep, step: 1}
+	if hasStart {
+		step.start = &start
+	}
+
+	p.consume(":")
+	if end, ok := p.int(); ok {
+		step.end = &end
+	}
+
+	if p.consume(":") {
+		pos := p.peek().pos
+		if s, ok := p.int(); ok {
+			if s == 0 {
+				return step, p.errorf(pos, "slice step should not be zero")
+			}
+			step.step = s
+		}
+	}
+
+	return step, nil
+}
+
+// integer with optional minus
+func (p *pathParser) int() (int, bool) {
+	st := p.index
+	negative := p.consume("-")
+
+	if t := p.peek(); t.kind == numberToken {
+		if i, ok := t.value.(int64); ok {
+			p.index++
+			if negative {
+				i = -i
+			}
+			return int(i), true
+		}
+	}
+
+	p.index = st
+	return 0, false
+}
+
+func (p *pathParser) or() (pathExpr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.consume("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *pathParser) and() (pathExpr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.consume("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *pathParser) unary() (pathExpr, error) {
+	if p.consume("!") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x: x}, nil
+	}
+
+	if p.consume("(") {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+
+	return p.comparison()
+}
+
+func (p *pathParser) comparison() (pathExpr, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return binaryExpr{op: op, left: left, right: right}, nil
+		}
+	}
+
+	return left, nil
+}
+
+var pathLiterals = map[string]interface{}{"true": true, "false": false, "null": nil}
+
+// numbers are float64 like decoded json
+func (p *pathParser) operand() (pathExpr, error) {
+	t := p.peek()
+	if value, ok := pathLiterals[t.text]; ok && t.kind == identToken {
+		p.index++
+		return literalExpr{value: value}, nil
+	}
+
+	switch {
+	case p.consume("@"):
+		steps, err := p.steps()
+		return nodeExpr{steps: steps}, err
+	case p.consume("$"):
+		steps, err := p.steps()
+		return nodeExpr{root: true, steps: steps}, err
+	case t.kind == stringToken:
+		p.index++
+		return literalExpr{value: t.value}, nil
+	}
+
+	negative := p.consume("-")
+	t = p.peek()
+	if t.kind != numberToken {
+		return nil, p.errorf(t.pos, "expected operand")
+	}
+	p.index++
+
+	f, _ := toNumber(t.value)
+	if negative {
+		f = -f
+	}
+
+	return literalExpr{value: f}, nil
+}
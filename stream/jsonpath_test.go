@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type pathOrder struct {
+	ID    int
+	Items []pathItem
+}
+
+type pathItem struct {
+	Name  string
+	Qty   int
+	Price float64
+}
+
+var _ = Describe("Test JSONPath", func() {
+	var doc interface{}
+
+	BeforeEach(func() {
+		data := `{
+			"store": "main",
+			"orders": [
+				{"id": 1, "items": [{"name": "a", "qty": 2, "price": 10}, {"name": "b", "qty": 0, "price": 5}]},
+				{"id": 2, "items": [{"name": "c", "qty": 1, "price": 7.5, "tags": ["x", "y"]}]},
+				{"id": 3, "items": []}
+			]
+		}`
+		Expect(json.Unmarshal([]byte(data), &doc)).To(BeNil())
+	})
+
+	It("should select children", func() {
+		Expect(OfPath(doc, "$.store").Interface()).To(Equal([]interface{}{"main"}))
+		Expect(OfPath(doc, "$['store']").Interface()).To(Equal([]interface{}{"main"}))
+		Expect(OfPath(doc, "$.orders[0].id").Interface()).To(Equal([]interface{}{1.0}))
+		Expect(OfPath(doc, "$.orders[-1].id").Interface()).To(Equal([]interface{}{3.0}))
+		Expect(OfPath(doc, "$.missing").Count()).To(Equal(0))
+	})
+	It("should select wildcards", func() {
+		Expect(OfPath(doc, "$.orders[*].id").Interface()).To(Equal([]interface{}{1.0, 2.0, 3.0}))
+		Expect(OfPath(doc, "$.orders.*.id").Interface()).To(Equal([]interface{}{1.0, 2.0, 3.0}))
+		Expect(OfPath(doc, "$.orders[1].items[0].*").Count()).To(Equal(4))
+	})
+	It("should select unions and slices", func() {
+		Expect(OfPath(doc, "$.orders[0,2].id").Interface()).To(Equal([]interface{}{1.0, 3.0}))
+		Expect(OfPath(doc, "$.orders[1:].id").Interface()).To(Equal([]interface{}{2.0, 3.0}))
+		Expect(OfPath(doc, "$.orders[:2].id").Interface()).To(Equal([]interface{}{1.0, 2.0}))
+		Expect(OfPath(doc, "$.orders[::-1].id").Interface()).To(Equal([]interface{}{3.0, 2.0, 1.0}))
+		Expect(OfPath(doc, "$.orders[0:3:2].id").Interface()).To(Equal([]interface{}{1.0, 3.0}))
+		Expect(OfPath(doc, "$.orders[0].items[0]['name','qty']").Interface()).To(Equal([]interface{}{"a", 2.0}))
+	})
+	It("should select recursively", func() {
+		Expect(OfPath(doc, "$..name").Interface()).To(Equal([]interface{}{"a", "b", "c"}))
+		Expect(OfPath(doc, "$..tags[1]").Interface()).To(Equal([]interface{}{"y"}))
+		Expect(OfPath(doc, "$..id").Count()).To(Equal(3))
+	})
+	It("should filter", func() {
+		v := OfPath(doc, "$.orders[*].items[?(@.qty>0)].price").Interface()
+		Expect(v).To(Equal([]interface{}{10.0, 7.5}))
+
+		v = OfPath(doc, "$..items[?(@.qty > 0 && @.price < 10)].name").Interface()
+		Expect(v).To(Equal([]interface{}{"c"}))
+
+		v = OfPath(doc, "$..items[?(@.name == 'b' || @.tags)].name").Interface()
+		Expect(v).To(Equal([]interface{}{"b", "c"}))
+
+		v = OfPath(doc, "$..items[?(!@.tags)].name").Interface()
+		Expect(v).To(Equal([]interface{}{"a", "b"}))
+
+		v = OfPath(doc, "$.orders[?(@.items[0].name != \"a\")].id").Interface()
+		Expect(v).To(Equal([]interface{}{2.0}))
+
+		v = OfPath(doc, "$.orders[?(@.id == $.orders[2].id)].id").Interface()
+		Expect(v).To(Equal([]interface{}{3.0}))
+	})
+	It("should walk structs", func() {
+		orders := []pathOrder{{ID: 1, Items: []pathItem{{Name: "a", Qty: 1, Price: 2}}}, {ID: 2}}
+		v := OfPath(orders, "$[*].Items[?(@.Qty >= 1)].Price").Interface()
+		Expect(v).To(Equal([]interface{}{2.0}))
+	})
+	It("should chain stream functions", func() {
+		v := OfPath(doc, "$..price").Filter(func(content Content) bool {
+			return content.Data.(float64) > 6
+		}).Interface()
+		Expect(v).To(Equal([]interface{}{10.0, 7.5}))
+	})
+	It("should set parse errors", func() {
+		for _, path := range []string{"$.", "$[", "$[?(@.a >)]", "$['a", "$.a]", "$[1:2:0]"} {
+			s := OfPath(doc, path)
+			Expect(s.Count()).To(Equal(0))
+			Expect(s.Err()).To(HaveOccurred(), path)
+		}
+		// position of unexpected token like expression errors
+		Expect(OfPath(doc, "$.a b").Err().Error()).To(ContainSubstring("position 4"))
+	})
+	It("should quote like expressions", func() {
+		data := map[string]interface{}{"it's": "a\tb", "items": []interface{}{map[string]interface{}{"name": "x\ty"}}}
+		Expect(OfPath(data, `$['it\'s']`).Interface()).To(Equal([]interface{}{"a\tb"}))
+		Expect(OfPath(data, `$.items[?(@.name == "x\ty")].name`).Count()).To(Equal(1))
+
+		e, err := CompileExpr(`name == "x\ty"`)
+		Expect(err).To(BeNil())
+		Expect(e.Match(Content{Data: map[string]interface{}{"name": "x\ty"}})).To(BeTrue())
+	})
+})
@@ -0,0 +1,646 @@
+package stream
+
+import (
+	"context"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// lazy stream pulls elements from an iterator when a terminal operation needs them.
+// slices and maps are iterator sources too, Filter, Map, Skip and Limit are applied one element at a time
+// so stream can be infinite or bigger than memory. sort, sample and top k read the stream first
+type lazy struct {
+	*pipeline
+	iterator    Iterator
+	workerCount int          //thread count of last Filter or Map, default of terminal operations
+	kind        reflect.Kind //items kind
+	format      reflect.Type //items format
+}
+
+// stream of iterator elements
+// new type required and it should be array slice or map, it is the type Interface returns
+func OfIterator(it Iterator, newType interface{}) IStream {
+	typeOf := typeOfNew(newType)
+
+	stream := &lazy{
+		pipeline: newPipeline(),
+		iterator: it,
+		kind:     itemsKind(typeOf.Kind()),
+		format:   itemsFormat(typeOf),
+	}
+
+	return stream
+}
+
+// stream of channel elements, it ends when channel is closed
+func OfChan(ch interface{}) IStream {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic("it should be a receive channel")
+	}
+
+	stream := &lazy{
+		pipeline: newPipeline(),
+		iterator: &chanIterator{ch: v},
+		kind:     reflect.Slice,
+		format:   reflect.SliceOf(v.Type().Elem()),
+	}
+
+	return stream
+}
+
+// new type should be array slice or map
+func typeOfNew(newType interface{}) reflect.Type {
+	typeOf := reflect.TypeOf(newType)
+	kind := typeOf.Kind()
+	if kind != reflect.Slice && kind != reflect.Array && kind != reflect.Map {
+		panic("newType should be slice,array or map")
+	}
+
+	return typeOf
+}
+
+// arrays are read into slices
+func itemsKind(kind reflect.Kind) reflect.Kind {
+	if kind == reflect.Map {
+		return kind
+	}
+
+	return reflect.Slice
+}
+
+func itemsFormat(typeOf reflect.Type) reflect.Type {
+	if typeOf.Kind() == reflect.Map {
+		return typeOf
+	}
+
+	return reflect.SliceOf(typeOf.Elem())
+}
+
+// value of data for format element, nil data is zero value
+func elemValue(data interface{}, t reflect.Type) reflect.Value {
+	if data == nil {
+		return reflect.Zero(t)
+	}
+
+	return reflect.ValueOf(data)
+}
+
+// thread count optional default is one. More thread breaks order of elements
+// use multiple thread if filter function execution takes too much time and order is not important
+func (s *lazy) Filter(f Filter, threadCount ...int) IStream {
+	return s.filter(s.guardFilter(f), getThreadCount(threadCount...))
+}
+
+// like Filter, elements with error are dropped and error is sent to dead letter sink or set to Err
+func (s *lazy) TryFilter(f TryFilter, threadCount ...int) IStream {
+	return s.filter(s.tryFilter("TryFilter", f), getThreadCount(threadCount...))
+}
+
+func (s *lazy) filter(f Filter, workerCount int) IStream {
+	s.workerCount = workerCount
+	if s.workerCount > 1 {
+		s.iterator = newParallelIterator(s.iterator, s.workerCount, func(c Content) []Content {
+			if f(c) {
+				return []Content{c}
+			}
+			return nil
+		})
+
+		return s
+	}
+
+	s.iterator = &filterIterator{source: s.iterator, f: f}
+
+	return s
+}
+
+// keep elements that match compiled expression, compile and evaluation errors are set to Err
+func (s *lazy) FilterExpr(expr string, threadCount ...int) IStream {
+	return s.Filter(s.exprFilter(expr), threadCount...)
+}
+
+// map elements to expression result converted to element of newType
+func (s *lazy) MapExpr(expr string, newType interface{}, threadCount ...int) IStream {
+	f, err := s.exprAction(expr, newType)
+	if err != nil {
+		return s.failed(err, newType)
+	}
+
+	return s.Map(f, newType, threadCount...)
+}
+
+// convert elements to element struct of newType by field names or `stream:"Source.Path"` tags
+func (s *lazy) MapTo(newType interface{}, threadCount ...int) IStream {
+	return s.mapTo(newType, false, threadCount...)
+}
+
+// like MapTo but every exported field of target should have a source field
+func (s *lazy) MapToStrict(newType interface{}, threadCount ...int) IStream {
+	return s.mapTo(newType, true, threadCount...)
+}
+
+func (s *lazy) mapTo(newType interface{}, strict bool, threadCount ...int) IStream {
+	f, err := s.mapToAction(newType, s.format.Elem(), strict)
+	if err != nil {
+		return s.failed(err, newType)
+	}
+
+	return s.Map(f, newType, threadCount...)
+}
+
+// keep elements whose value at path exists and matches f
+func (s *lazy) FilterPath(path string, f func(interface{}) bool, threadCount ...int) IStream {
+	return s.Filter(pathFilter(path, f), threadCount...)
+}
+
+// []interface{} stream of values at path, elements without path are skipped
+func (s *lazy) Pluck(path string) IStream {
+	return s.Filter(hasPath(path)).Map(getPath(path), []interface{}{})
+}
+
+// apply action to elements as they are pulled
+// new type required and it should be array slice or map, slice and map results are flattened
+// thread count optional default is one. More thread breaks order of elements
+// use multiple thread if Action function execution takes too much time and order is not important
+func (s *lazy) Map(f Action, newType interface{}, threadCount ...int) IStream {
+	return s.mapWith(s.guardAction(f, newType), newType, getThreadCount(threadCount...))
+}
+
+// like Map, elements with error are dropped and error is sent to dead letter sink or set to Err
+func (s *lazy) TryMap(f TryAction, newType interface{}, threadCount ...int) IStream {
+	return s.mapWith(s.tryAction("TryMap", f, newType), newType, getThreadCount(threadCount...))
+}
+
+// like TryMap, failing elements are retried with policy before they are dropped
+func (s *lazy) MapWithRetry(f TryAction, newType interface{}, policy RetryPolicy, threadCount ...int) IStream {
+	return s.mapWith(s.retryAction(f, newType, policy), newType, getThreadCount(threadCount...))
+}
+
+// workers of every Map stage take next element when they are done, so a slow element does not hold others
+func (s *lazy) mapWith(f Action, newType interface{}, workerCount int) IStream {
+	typeOf := typeOfNew(newType)
+
+	s.workerCount = workerCount
+	if s.workerCount > 1 {
+		s.iterator = newParallelIterator(s.iterator, s.workerCount, func(c Content) []Content {
+			return flatten(f(c), typeOf)
+		})
+	} else {
+		s.iterator = &actionIterator{source: s.iterator, f: f, format: typeOf}
+	}
+
+	s.kind = itemsKind(typeOf.Kind())
+	s.format = itemsFormat(typeOf)
+
+	return s
+}
+
+// skip first i elements
+func (s *lazy) Skip(i int) IStream {
+	if i > 0 {
+		s.iterator = &skipIterator{source: s.iterator, skip: i}
+	}
+
+	return s
+}
+
+// read i element from start, source is not pulled after limit
+func (s *lazy) Limit(i int) IStream {
+	if i > 0 {
+		s.iterator = &limitIterator{source: s.iterator, limit: i}
+	}
+
+	return s
+}
+
+// elements until first element that does not match, source is not pulled after it
+func (s *lazy) TakeWhile(f Filter) IStream {
+	s.iterator = &takeWhileIterator{source: s.iterator, f: f}
+
+	return s
+}
+
+// read all elements
+func (s *lazy) contents() []Content {
+	var contents []Content
+	s.fail(drain(s.iterator, func(c Content) bool {
+		contents = append(contents, c)
+		return true
+	}))
+
+	return contents
+}
+
+// all elements with index accessor for terminal operations
+func (s *lazy) elements() (int, func(i int) Content) {
+	contents := s.contents()
+
+	return len(contents), func(i int) Content {
+		return contents[i]
+	}
+}
+
+// slice or map of stream format
+func (s *lazy) items(contents []Content) reflect.Value {
+	if s.kind == reflect.Map {
+		items := reflect.MakeMapWithSize(s.format, len(contents))
+		for _, c := range contents {
+			items.SetMapIndex(elemValue(c.Key, s.format.Key()), elemValue(c.Data, s.format.Elem()))
+		}
+		return items
+	}
+
+	items := reflect.MakeSlice(s.format, len(contents), len(contents))
+	for i, c := range contents {
+		items.Index(i).Set(elemValue(c.Data, s.format.Elem()))
+	}
+	return items
+}
+
+// stream goes on with contents read by a previous operation
+func (s *lazy) from(contents []Content) IStream {
+	items := s.items(contents)
+	if s.kind == reflect.Map {
+		s.iterator = &entryIterator{items: items, keys: items.MapKeys()}
+	} else {
+		s.iterator = &sliceIterator{items: items}
+	}
+
+	return s
+}
+
+// empty stream of newType with err, source is closed without reading it
+func (s *lazy) failed(err error, newType interface{}) IStream {
+	typeOf := typeOfNew(newType)
+
+	s.fail(err)
+	s.fail(s.iterator.Close())
+	s.kind = itemsKind(typeOf.Kind())
+	s.format = itemsFormat(typeOf)
+
+	return s.from(nil)
+}
+
+// sorting
+// maps are not sorted since key order changes in run time
+func (s *lazy) SortBy(f Compare) IStream {
+	if s.kind == reflect.Map {
+		return s
+	}
+
+	contents := s.contents()
+	sort.Slice(contents, func(x, y int) bool {
+		return f(contents[x], contents[y]) > 0
+	})
+
+	return s.from(contents)
+}
+
+// stable sorting, equal elements keep their order
+// maps are not sorted since key order changes in run time
+func (s *lazy) SortStableBy(f Compare) IStream {
+	if s.kind == reflect.Map {
+		return s
+	}
+
+	entries := makeSortEntries(s.contents())
+	stableSort(entries, f)
+
+	return s.from(entryContents(entries))
+}
+
+// stable parallel merge sort
+// thread count optional, default is the stream's worker count
+// maps are not sorted since key order changes in run time
+func (s *lazy) ParallelSortBy(f Compare, threadCount ...int) IStream {
+	if s.kind == reflect.Map {
+		return s
+	}
+
+	workerCount := s.workerCount
+	if len(threadCount) > 0 {
+		workerCount = getThreadCount(threadCount...)
+	}
+
+	entries := parallelMergeSort(makeSortEntries(s.contents()), f, workerCount)
+
+	return s.from(entryContents(entries))
+}
+
+// first k elements in f order, same as SortBy(f).Limit(k) without sorting whole stream
+// result of map is a map so order is lost
+// thread count optional, default is the stream's worker count
+func (s *lazy) TopK(k int, f Compare, threadCount ...int) IStream {
+	return s.topK(k, f, false, threadCount...)
+}
+
+// last k elements in f order
+// thread count optional, default is the stream's worker count
+func (s *lazy) BottomK(k int, f Compare, threadCount ...int) IStream {
+	return s.topK(k, f, true, threadCount...)
+}
+
+// n random elements with reservoir sampling in one pass, only n elements are kept and they keep their order
+// seed optional for reproducible samples
+func (s *lazy) Sample(n int, seed ...int64) IStream {
+	contents, err := reservoir(s.sampleSource(), n, newRand(seed...))
+	s.fail(err)
+
+	return s.from(contents)
+}
+
+// every element is kept with probability p as it is pulled, so source can be infinite
+// seed optional for reproducible samples
+func (s *lazy) SampleFraction(p float64, seed ...int64) IStream {
+	r := newRand(seed...)
+	s.iterator = &filterIterator{source: s.sampleSource(), f: func(Content) bool {
+		return r.Float64() < p
+	}}
+
+	return s
+}
+
+// random order
+// seed optional for reproducible order, maps are not shuffled since key order changes in run time
+func (s *lazy) Shuffle(seed ...int64) IStream {
+	if s.kind == reflect.Map {
+		return s
+	}
+
+	contents := s.contents()
+
+	return s.pick(contents, newRand(seed...).Perm(len(contents)))
+}
+
+// min max
+func (s *lazy) FindEdge(f CompareConditional) interface{} {
+	var selected *Content
+	s.fail(drain(s.iterator, func(c Content) bool {
+		if selected == nil || f(c, *selected) {
+			current := c
+			selected = &current
+		}
+		return true
+	}))
+
+	if selected == nil {
+		return nil
+	}
+
+	return selected.Data
+}
+
+// number of elements after pending stages, stream is used up after it like other terminal functions
+func (s *lazy) Count() int {
+	count := 0
+	s.fail(drain(s.iterator, func(Content) bool {
+		count++
+		return true
+	}))
+
+	return count
+}
+
+// p percentile of numbers, p in [0, 100]
+// extract function optional, default reads Data as number
+func (s *lazy) Percentile(p float64, f ...Extract) float64 {
+	length, content := s.elements()
+
+	return percentileOf(length, content, p, s.workerCount, f...)
+}
+
+func (s *lazy) Median(f ...Extract) float64 {
+	return s.Percentile(50, f...)
+}
+
+// population variance
+func (s *lazy) Variance(f ...Extract) float64 {
+	length, content := s.elements()
+
+	return varianceOf(length, content, s.workerCount, f...)
+}
+
+func (s *lazy) StdDev(f ...Extract) float64 {
+	return math.Sqrt(s.Variance(f...))
+}
+
+// count of numbers per bucket, buckets are ascending upper bounds
+func (s *lazy) Histogram(buckets []float64, f ...Extract) []Bucket {
+	length, content := s.elements()
+
+	return histogram(length, content, buckets, s.workerCount, f...)
+}
+
+// count, min, max, mean, standard deviation and percentiles in one pass
+func (s *lazy) Describe(f ...Extract) Summary {
+	length, content := s.elements()
+
+	return describe(length, content, s.workerCount, f...)
+}
+
+// approximate distinct count with HyperLogLog, relative error default is 0.01
+func (s *lazy) ApproxDistinctCount(relativeError float64) int {
+	length, content := s.elements()
+
+	return approxDistinctCount(length, content, relativeError, s.workerCount)
+}
+
+// approximate k most frequent values with count-min sketch, most frequent first
+// counts overestimate at most epsilon*count with probability 1-delta, defaults are 0.001 and 0.01
+func (s *lazy) ApproxTopK(k int, epsilon, delta float64) []Frequency {
+	length, content := s.elements()
+
+	return approxTopK(length, content, k, epsilon, delta, s.workerCount)
+}
+
+// approximate q quantile with t-digest, q in [0, 1]
+// compression default is 100, higher is more accurate
+func (s *lazy) ApproxQuantile(q, compression float64, f ...Extract) float64 {
+	length, content := s.elements()
+
+	return approxQuantile(length, content, q, compression, s.workerCount, f...)
+}
+
+// stops reading at first match
+func (s *lazy) AnyMatch(f Filter) bool {
+	matched := false
+	s.fail(drain(s.iterator, func(c Content) bool {
+		matched = f(c)
+		return !matched
+	}))
+
+	return matched
+}
+
+// stops reading at first mismatch
+func (s *lazy) AllMatch(f Filter) bool {
+	matched := true
+	s.fail(drain(s.iterator, func(c Content) bool {
+		matched = f(c)
+		return matched
+	}))
+
+	return matched
+}
+
+// reads only first element
+// it gives random result for maps since key order changes in run time
+func (s *lazy) FindFirst() interface{} {
+	var first interface{}
+	s.fail(drain(s.iterator, func(c Content) bool {
+		first = c.Data
+		return false
+	}))
+
+	return first
+}
+
+// it gives random result for maps since key order changes in run time
+func (s *lazy) FindLast() interface{} {
+	var last interface{}
+	s.fail(drain(s.iterator, func(c Content) bool {
+		last = c.Data
+		return true
+	}))
+
+	return last
+}
+
+// slice or map of all elements
+func (s *lazy) Interface() interface{} {
+	return s.items(s.contents()).Interface()
+}
+
+// elements are sent when channel has room, so a slow reader slows down the stream.
+// closing optional done channel stops the stream and closes its source
+func (s *lazy) ToChan(bufferSize int, done ...<-chan struct{}) <-chan Content {
+	return s.toChan(s.iterator, bufferSize, done...)
+}
+
+// f is called for every element on thread count goroutines
+// returned channel is closed when all elements are handled
+func (s *lazy) ForEachAsync(f func(Content), threadCount ...int) <-chan struct{} {
+	return s.forEachAsync(f, getThreadCount(threadCount...))
+}
+
+// forEach in a goroutine, error of iterator is set before done channel is closed
+func (s *lazy) forEachAsync(f func(Content), workerCount int) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.forEach(context.Background(), s.iterator, s.guardEach(f), workerCount)
+	}()
+
+	return done
+}
+
+// f is called for every element, it returns when all elements are handled
+func (s *lazy) ForEach(f func(Content)) {
+	s.ForEachParallel(f, 1)
+}
+
+// like ForEach on thread count goroutines
+func (s *lazy) ForEachParallel(f func(Content), threadCount int) {
+	s.forEach(context.Background(), s.iterator, s.guardEach(f), getThreadCount(threadCount))
+}
+
+// like ForEach, first error of f cancels ctx given to f, stops pulling elements and it is returned.
+// it also returns when ctx is done
+func (s *lazy) TryForEach(ctx context.Context, f func(context.Context, Content) error, threadCount ...int) error {
+	return s.tryForEach(ctx, s.iterator, f, getThreadCount(threadCount...))
+}
+
+// one row per element, first column is key of maps. header is written when options Header is set
+func (s *lazy) WriteCSV(w io.Writer, opts CSVOptions) error {
+	return s.writeCSV(w, s.iterator, s.format.Elem(), s.kind == reflect.Map, opts)
+}
+
+// elements are written as json array, entries of maps as json object
+func (s *lazy) WriteJSON(w io.Writer) error {
+	return s.writeJSON(w, s.iterator, s.kind == reflect.Map, false)
+}
+
+// one element or single key object per line
+func (s *lazy) WriteNDJSON(w io.Writer) error {
+	return s.writeJSON(w, s.iterator, s.kind == reflect.Map, true)
+}
+
+// elements as aligned text table, first column is key of maps
+func (s *lazy) RenderTable(w io.Writer, opts TableOptions) error {
+	return s.renderTable(w, s.iterator, s.format.Elem(), s.kind == reflect.Map, opts)
+}
+
+// panics and errors of Filter, Map and Try stages added after it go to sink instead of stopping the stream.
+// those stages are counted in Trace
+func (s *lazy) DeadLetter(sink DeadLetterSink) IStream {
+	s.setDeadLetter(sink)
+
+	return s
+}
+
+// Map, TryMap, MapWithRetry and ForEach stages added after it wait for tokens of their own bucket,
+// workers of a stage share it. waits are summed in Trace
+func (s *lazy) RateLimit(limit RateLimit) IStream {
+	s.setRateLimit(limit)
+
+	return s
+}
+
+// Map, TryMap, MapWithRetry and ForEach stages added after it call their function through breaker, nil removes it
+func (s *lazy) Breaker(b *CircuitBreaker) IStream {
+	s.setBreaker(b)
+
+	return s
+}
+
+// Validate stages added after it send violations to sink instead of keeping them for Violations,
+// so they can be handled while a long stream runs
+func (s *lazy) OnViolation(sink ViolationSink) IStream {
+	s.setViolationSink(sink)
+
+	return s
+}
+
+// keep elements that satisfy their `validate` tags, others are reported by Violations
+// with their key for maps and their index in this stage for others.
+// elements are checked as they are pulled, thread count optional default is one. More thread breaks order of elements
+func (s *lazy) Validate(threadCount ...int) IStream {
+	s.iterator = s.validateIterator(s.iterator, s.format.Elem(), s.kind == reflect.Map, getThreadCount(threadCount...))
+
+	return s
+}
+
+func (s *lazy) Iterator() Iterator {
+	return s.iterator
+}
+
+// error of iterator is set before channel is closed
+// sending goroutine returns when done is closed, so a reader can stop early without leaking it
+func (p *pipeline) toChan(it Iterator, bufferSize int, done ...<-chan struct{}) <-chan Content {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+
+	var stop <-chan struct{}
+	if len(done) > 0 {
+		stop = done[0]
+	}
+
+	c := make(chan Content, bufferSize)
+	go func() {
+		defer close(c)
+		p.fail(drain(it, func(content Content) bool {
+			select {
+			case c <- content:
+				return true
+			case <-stop:
+				return false
+			}
+		}))
+	}()
+
+	return c
+}
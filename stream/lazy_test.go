@@ -0,0 +1,193 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Lazy", func() {
+	var source chan testModel
+	BeforeEach(func() {
+		source = make(chan testModel, 10)
+		for i := 1; i <= 9; i++ {
+			source <- testModel{Id: i, Name: string(rune('a' + i - 1))}
+		}
+		close(source)
+	})
+	Describe("OfChan", func() {
+		It("should panic when it is not a channel", func() {
+			Expect(func() { OfChan([]int{}) }).To(Panic())
+			Expect(func() { OfChan(make(chan<- int)) }).To(Panic())
+		})
+		It("should get an interface", func() {
+			v := OfChan(source).Interface().([]testModel)
+			Expect(len(v)).To(Equal(9))
+			Expect(v[0].Name).To(Equal("a"))
+		})
+		It("should apply filter and map", func() {
+			v := OfChan(source).
+				Filter(func(content Content) bool {
+					return content.Data.(testModel).Id > 5
+				}).
+				Map(func(content Content) Content {
+					return Content{Data: content.Data.(testModel).Name}
+				}, []string{}).
+				Interface().([]string)
+			Expect(v).To(Equal([]string{"f", "g", "h", "i"}))
+		})
+		It("should map to map", func() {
+			v := OfChan(source).
+				Map(func(content Content) Content {
+					c := content.Data.(testModel)
+					return Content{Key: c.Name, Data: c.Id}
+				}, map[string]int{}).
+				Interface().(map[string]int)
+			Expect(len(v)).To(Equal(9))
+			Expect(v["c"]).To(Equal(3))
+		})
+		It("should flatten slices", func() {
+			v := OfChan(source).
+				Limit(2).
+				Map(func(content Content) Content {
+					c := content.Data.(testModel)
+					return Content{Data: []int{c.Id, c.Id * 10}}
+				}, []int{}).
+				Interface()
+			Expect(v).To(Equal([]int{1, 10, 2, 20}))
+		})
+		It("should skip and stop reading after limit", func() {
+			v := OfChan(source).Skip(2).Limit(3).Interface().([]testModel)
+			Expect(len(v)).To(Equal(3))
+			Expect(v[0].Id).To(Equal(3))
+			Expect(len(source)).To(Equal(4))
+		})
+		It("should stop reading at first match", func() {
+			res := OfChan(source).AnyMatch(func(content Content) bool {
+				return content.Data.(testModel).Id == 2
+			})
+			Expect(res).To(BeTrue())
+			Expect(len(source)).To(Equal(7))
+		})
+		It("should collect for sort and terminal operations", func() {
+			res := OfChan(source).SortBy(func(content Content, content2 Content) int {
+				return content.Data.(testModel).Id - content2.Data.(testModel).Id
+			}).FindFirst()
+			Expect(res.(testModel).Id).To(Equal(9))
+		})
+		It("should count", func() {
+			Expect(OfChan(source).Count()).To(Equal(9))
+		})
+		It("should find first and last", func() {
+			Expect(OfChan(source).FindFirst().(testModel).Id).To(Equal(1))
+			Expect(OfChan(source).FindLast().(testModel).Id).To(Equal(9))
+		})
+		It("should map elements as they arrive", func() {
+			input := make(chan int)
+			output := OfChan(input).
+				Map(func(content Content) Content {
+					return Content{Data: content.Data.(int) * 2}
+				}, []int{}).
+				ToChan(0)
+			for i := 1; i <= 3; i++ {
+				input <- i
+				Expect((<-output).Data).To(Equal(i * 2))
+			}
+			close(input)
+			_, ok := <-output
+			Expect(ok).To(BeFalse())
+		})
+	})
+	Describe("single use", func() {
+		It("should be used up after a terminal function", func() {
+			s := Of([]int{1, 2, 3, 4}).Filter(func(content Content) bool {
+				return content.Data.(int) > 1
+			})
+			Expect(s.Count()).To(Equal(3))
+			Expect(s.Interface()).To(Equal([]int{}))
+			Expect(s.FindFirst()).To(BeNil())
+
+			m := Of(map[string]int{"a": 1})
+			Expect(m.Count()).To(Equal(1))
+			Expect(m.Interface()).To(Equal(map[string]int{}))
+		})
+	})
+	Describe("ToChan", func() {
+		It("should not read source before reader is ready", func() {
+			output := OfChan(source).ToChan(0)
+			Expect((<-output).Data.(testModel).Id).To(Equal(1))
+			Eventually(func() int { return len(source) }).Should(Equal(7))
+			Consistently(func() int { return len(source) }).Should(Equal(7))
+			count := 1
+			for range output {
+				count++
+			}
+			Expect(count).To(Equal(9))
+		})
+		It("should stop and close source when done is closed", func() {
+			it := &countingIterator{}
+			done := make(chan struct{})
+			output := OfIterator(it, []int{}).ToChan(0, done)
+			Expect((<-output).Data).To(Equal(1))
+			Expect((<-output).Data).To(Equal(2))
+			close(done)
+
+			Eventually(it.isClosed).Should(BeTrue())
+			Eventually(output).Should(BeClosed())
+		})
+		It("should send list elements in order", func() {
+			var ids []int
+			for c := range Of([]int{1, 2, 3}).ToChan(1) {
+				ids = append(ids, c.Data.(int))
+			}
+			Expect(ids).To(Equal([]int{1, 2, 3}))
+		})
+		It("should send map entries with key", func() {
+			res := map[interface{}]interface{}{}
+			for c := range Of(map[string]int{"a": 1, "b": 2}).ToChan(0) {
+				res[c.Key] = c.Data
+			}
+			Expect(res).To(Equal(map[interface{}]interface{}{"a": 1, "b": 2}))
+		})
+	})
+	Describe("ForEachAsync", func() {
+		It("should handle all elements", func() {
+			var mutex sync.Mutex
+			sum := 0
+			<-OfChan(source).ForEachAsync(func(content Content) {
+				mutex.Lock()
+				sum += content.Data.(testModel).Id
+				mutex.Unlock()
+			}, 4)
+			Expect(sum).To(Equal(45))
+		})
+		It("should handle all list elements with workers", func() {
+			var mutex sync.Mutex
+			sum := 0
+			<-Of([]int{1, 2, 3, 4}).(*lazy).forEachAsync(func(content Content) {
+				mutex.Lock()
+				sum += content.Data.(int)
+				mutex.Unlock()
+			}, 3)
+			Expect(sum).To(Equal(10))
+		})
+		It("should run workers at the same time", func() {
+			var wg sync.WaitGroup
+			wg.Add(4)
+			started := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(started)
+			}()
+
+			// every worker waits for others, so one worker alone would not finish
+			done := Range(0, 4, 1).(*lazy).forEachAsync(func(content Content) {
+				wg.Done()
+				<-started
+			}, 4)
+			Eventually(done, time.Second).Should(BeClosed())
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package stream
 
 import (
+	"math/rand"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 )
@@ -63,3 +66,66 @@ func BenchmarkList_MapParallel(b *testing.B) {
 		}, []string{}, 4)
 	}
 }
+
+func makeSortBenchmarkArray() []testModel {
+	r := rand.New(rand.NewSource(1))
+	arr := make([]testModel, 1000000)
+	for i := range arr {
+		arr[i] = testModel{Id: r.Intn(len(arr))}
+	}
+
+	return arr
+}
+
+func sortBenchmarkCompare(content Content, content2 Content) int {
+	return content2.Data.(testModel).Id - content.Data.(testModel).Id
+}
+
+func BenchmarkList_SortBy(b *testing.B) {
+	arr := makeSortBenchmarkArray()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		Of(arr).SortBy(sortBenchmarkCompare)
+	}
+}
+
+// less of the old SortBy, every comparison reads items by reflect.Value.Index
+func makeLess(items reflect.Value, f Compare) func(i, j int) bool {
+	return func(x, y int) bool {
+		c1 := Content{
+			Data: items.Index(x).Interface(),
+		}
+
+		c2 := Content{
+			Data: items.Index(y).Interface(),
+		}
+
+		return f(c1, c2) > 0
+	}
+}
+
+// old SortBy to compare with sort of contents
+func BenchmarkList_SortByReflect(b *testing.B) {
+	arr := makeSortBenchmarkArray()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		items := reflect.ValueOf(append([]testModel(nil), arr...))
+		sort.Slice(items.Interface(), makeLess(items, sortBenchmarkCompare))
+	}
+}
+
+func BenchmarkList_SortStableBy(b *testing.B) {
+	arr := makeSortBenchmarkArray()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		Of(arr).SortStableBy(sortBenchmarkCompare)
+	}
+}
+
+func BenchmarkList_ParallelSortBy(b *testing.B) {
+	arr := makeSortBenchmarkArray()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		Of(arr).ParallelSortBy(sortBenchmarkCompare, 4)
+	}
+}
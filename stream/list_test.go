@@ -1,6 +1,8 @@
 package stream
 
 import (
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -167,9 +169,19 @@ var _ = Describe("Test Stream", func() {
 					})
 				v, ok := res.Interface().([]testModel)
 				Expect(ok).To(Equal(true))
-				Expect(len(v)).To(Equal(2))
-				Expect(v[0]).To(Equal(testArray[4]))
-				Expect(v[1]).To(Equal(testArray[5]))
+				Expect(v).To(Equal(testArray[2:6]))
+			})
+			It("should not map elements after limit", func() {
+				calls := 0
+				res := Of(make([]int, 1000)).
+					Map(func(content Content) Content {
+						calls++
+						return content
+					}, []int{}).
+					Limit(2).
+					Interface()
+				Expect(res).To(Equal([]int{0, 0}))
+				Expect(calls).To(Equal(2))
 			})
 			It("should apply filter skip, limit, map and sort", func() {
 				res := Of(testArray).
@@ -384,6 +396,12 @@ var _ = Describe("Test Stream", func() {
 				Expect(v[0]).To(Equal(testArray[0].List[0]))
 				Expect(v[1]).To(Equal(testArray[0].List[1]))
 			})
+			It("should append nested slices", func() {
+				v := Of([]string{"a b", "c"}).Map(func(content Content) Content {
+					return Content{Data: [][]string{strings.Fields(content.Data.(string))}}
+				}, [][]string{}).Interface()
+				Expect(v).To(Equal([][]string{{"a", "b"}, {"c"}}))
+			})
 			It("should filter and map internal array parallel", func() {
 				internalMap := map[string]testModel{
 					"x": {Id: 2, Name: "b", List: []testModel{{Name: "internal"}, {Name: "internal2"}}},
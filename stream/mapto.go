@@ -0,0 +1,283 @@
+package stream
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// plans are compiled once per source and target type
+var mapPlans sync.Map
+
+type mapKey struct {
+	src, dst reflect.Type
+	strict   bool
+}
+
+type mapPlan struct {
+	convert converter
+	err     error
+}
+
+// dst is settable value of target type
+type converter func(src, dst reflect.Value)
+
+type fieldPlan struct {
+	// field indices from source struct, pointers between them are followed
+	src     []int
+	dst     int
+	convert converter
+}
+
+type structPlan struct {
+	fields []fieldPlan
+}
+
+// action that converts Data to element type of newType, conversion errors are set to pipeline
+// and element is dropped. plan of srcType is compiled now unless it is interface, its error is returned without an action
+func (p *pipeline) mapToAction(newType interface{}, srcType reflect.Type, strict bool) (Action, error) {
+	typeOf := typeOfNew(newType)
+	elem := typeOf.Elem()
+
+	if srcType.Kind() != reflect.Interface {
+		if _, err := planOf(srcType, elem, strict); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(c Content) Content {
+		out := reflect.New(elem).Elem()
+		if c.Data != nil {
+			convert, err := planOf(reflect.TypeOf(c.Data), elem, strict)
+			if err != nil {
+				p.fail(err)
+				return emptyOf(typeOf)
+			}
+			convert(reflect.ValueOf(c.Data), out)
+		}
+
+		return Content{Key: c.Key, Data: out.Interface()}
+	}, nil
+}
+
+func planOf(src, dst reflect.Type, strict bool) (converter, error) {
+	key := mapKey{src: src, dst: dst, strict: strict}
+	if plan, ok := mapPlans.Load(key); ok {
+		return plan.(mapPlan).convert, plan.(mapPlan).err
+	}
+
+	c := &mapCompiler{strict: strict, structs: map[mapKey]*structPlan{}}
+	convert, err := c.compile(src, dst, dst.String())
+	if err != nil {
+		err = fmt.Errorf("cannot map %s to %s: %v", src, dst, err)
+		convert = nil
+	}
+	mapPlans.Store(key, mapPlan{convert: convert, err: err})
+
+	return convert, err
+}
+
+type mapCompiler struct {
+	strict bool
+	// plans in progress, recursive types use the same plan
+	structs map[mapKey]*structPlan
+}
+
+// path is target field path for errors
+func (c *mapCompiler) compile(src, dst reflect.Type, path string) (converter, error) {
+	switch {
+	case src.AssignableTo(dst):
+		return func(s, d reflect.Value) {
+			d.Set(s)
+		}, nil
+	case src.Kind() == reflect.Ptr:
+		elem, err := c.compile(src.Elem(), dst, path)
+		if err != nil {
+			return nil, err
+		}
+		return func(s, d reflect.Value) {
+			if s.IsNil() {
+				d.Set(reflect.Zero(d.Type()))
+				return
+			}
+			elem(s.Elem(), d)
+		}, nil
+	case dst.Kind() == reflect.Ptr:
+		elem, err := c.compile(src, dst.Elem(), path)
+		if err != nil {
+			return nil, err
+		}
+		return func(s, d reflect.Value) {
+			v := reflect.New(d.Type().Elem())
+			elem(s, v.Elem())
+			d.Set(v)
+		}, nil
+	case isNumberKind(src.Kind()) && isNumberKind(dst.Kind()),
+		src.Kind() == reflect.String && dst.Kind() == reflect.String,
+		src.Kind() == reflect.Bool && dst.Kind() == reflect.Bool:
+		return func(s, d reflect.Value) {
+			d.Set(s.Convert(d.Type()))
+		}, nil
+	case src.Kind() == reflect.Struct && dst.Kind() == reflect.Struct:
+		return c.compileStruct(src, dst, path)
+	case (src.Kind() == reflect.Slice || src.Kind() == reflect.Array) &&
+		(dst.Kind() == reflect.Slice || dst.Kind() == reflect.Array):
+		return c.compileList(src, dst, path)
+	case src.Kind() == reflect.Map && dst.Kind() == reflect.Map:
+		return c.compileMap(src, dst, path)
+	}
+
+	return nil, fmt.Errorf("%s: %s is not convertible to %s", path, src, dst)
+}
+
+func (c *mapCompiler) compileStruct(src, dst reflect.Type, path string) (converter, error) {
+	key := mapKey{src: src, dst: dst, strict: c.strict}
+	plan, ok := c.structs[key]
+	if !ok {
+		plan = &structPlan{}
+		c.structs[key] = plan
+
+		for i := 0; i < dst.NumField(); i++ {
+			f := dst.Field(i)
+			tag := f.Tag.Get("stream")
+			if f.PkgPath != "" || tag == "-" {
+				continue
+			}
+
+			name := f.Name
+			if tag != "" {
+				name = tag
+			}
+			fieldPath := path + "." + f.Name
+
+			index, srcType, found := sourceField(src, name)
+			if !found {
+				if c.strict {
+					return nil, fmt.Errorf("%s: no source field %s in %s", fieldPath, name, src)
+				}
+				continue
+			}
+
+			convert, err := c.compile(srcType, f.Type, fieldPath)
+			if err != nil {
+				return nil, err
+			}
+			plan.fields = append(plan.fields, fieldPlan{src: index, dst: i, convert: convert})
+		}
+	}
+
+	return func(s, d reflect.Value) {
+	fields:
+		for _, f := range plan.fields {
+			v := s
+			for _, i := range f.src {
+				if v.Kind() == reflect.Ptr {
+					if v.IsNil() {
+						continue fields
+					}
+					v = v.Elem()
+				}
+				v = v.Field(i)
+			}
+			f.convert(v, d.Field(f.dst))
+		}
+	}, nil
+}
+
+// exported field at dot separated path, names are matched exactly first then case insensitive
+func sourceField(t reflect.Type, path string) ([]int, reflect.Type, bool) {
+	var index []int
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, nil, false
+		}
+
+		f, ok := t.FieldByName(name)
+		if !ok || f.PkgPath != "" {
+			f, ok = t.FieldByNameFunc(func(field string) bool {
+				return strings.EqualFold(field, name)
+			})
+		}
+		if !ok || f.PkgPath != "" {
+			return nil, nil, false
+		}
+
+		index = append(index, f.Index...)
+		t = f.Type
+	}
+
+	return index, t, true
+}
+
+// arrays are filled up to their length
+func (c *mapCompiler) compileList(src, dst reflect.Type, path string) (converter, error) {
+	elem, err := c.compile(src.Elem(), dst.Elem(), path+"[]")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(s, d reflect.Value) {
+		if s.Kind() == reflect.Slice && s.IsNil() {
+			d.Set(reflect.Zero(d.Type()))
+			return
+		}
+
+		n := s.Len()
+		out := d
+		if d.Kind() == reflect.Slice {
+			out = reflect.MakeSlice(d.Type(), n, n)
+		} else if d.Len() < n {
+			n = d.Len()
+		}
+		for i := 0; i < n; i++ {
+			elem(s.Index(i), out.Index(i))
+		}
+		if d.Kind() == reflect.Slice {
+			d.Set(out)
+		}
+	}, nil
+}
+
+func (c *mapCompiler) compileMap(src, dst reflect.Type, path string) (converter, error) {
+	key, err := c.compile(src.Key(), dst.Key(), path+"[key]")
+	if err != nil {
+		return nil, err
+	}
+	elem, err := c.compile(src.Elem(), dst.Elem(), path+"[]")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(s, d reflect.Value) {
+		if s.IsNil() {
+			d.Set(reflect.Zero(d.Type()))
+			return
+		}
+
+		out := reflect.MakeMapWithSize(d.Type(), s.Len())
+		iter := s.MapRange()
+		for iter.Next() {
+			k := reflect.New(dst.Key()).Elem()
+			key(iter.Key(), k)
+			v := reflect.New(dst.Elem()).Elem()
+			elem(iter.Value(), v)
+			out.SetMapIndex(k, v)
+		}
+		d.Set(out)
+	}, nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mapAddress struct {
+	City string
+	Zip  int
+}
+
+type mapUserModel struct {
+	Id        int64
+	Name      string
+	Email     string
+	Address   *mapAddress
+	Tags      []string
+	Scores    map[string]int
+	CreatedAt time.Time
+	password  string
+}
+
+type mapAddressDTO struct {
+	City string
+	Zip  float64
+}
+
+type mapUserDTO struct {
+	ID        int
+	Name      string
+	Mail      string `stream:"Email"`
+	City      string `stream:"Address.City"`
+	Address   mapAddressDTO
+	Tags      []string
+	Scores    map[string]float64
+	CreatedAt *time.Time
+	Ignored   string `stream:"-"`
+}
+
+type mapNode struct {
+	Value int
+	Next  *mapNode
+}
+
+type mapNodeDTO struct {
+	Value float64
+	Next  *mapNodeDTO
+}
+
+var _ = Describe("Test MapTo", func() {
+	created := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	users := []mapUserModel{
+		{Id: 1, Name: "ali", Email: "ali@x.com", Address: &mapAddress{City: "istanbul", Zip: 34000},
+			Tags: []string{"a"}, Scores: map[string]int{"go": 5}, CreatedAt: created, password: "secret"},
+		{Id: 2, Name: "veli"},
+	}
+
+	It("should map fields by name and tag", func() {
+		s := Of(users).MapTo([]mapUserDTO{})
+		Expect(s.Err()).To(BeNil())
+		Expect(s.Interface()).To(Equal([]mapUserDTO{
+			{ID: 1, Name: "ali", Mail: "ali@x.com", City: "istanbul", Address: mapAddressDTO{City: "istanbul", Zip: 34000},
+				Tags: []string{"a"}, Scores: map[string]float64{"go": 5}, CreatedAt: &created},
+			{ID: 2, Name: "veli", CreatedAt: &time.Time{}},
+		}))
+	})
+	It("should map pointers and recursive types", func() {
+		list := &mapNode{Value: 1, Next: &mapNode{Value: 2}}
+		v := Of([]*mapNode{list, nil}).MapTo([]mapNodeDTO{}).Interface()
+		Expect(v).To(Equal([]mapNodeDTO{{Value: 1, Next: &mapNodeDTO{Value: 2}}, {}}))
+
+		v = Of(map[string]mapNode{"a": {Value: 3}}).MapTo(map[string]*mapNodeDTO{}).Interface()
+		Expect(v).To(Equal(map[string]*mapNodeDTO{"a": {Value: 3}}))
+	})
+	It("should map lazy streams", func() {
+		s := Range(0, 3, 1).Map(func(content Content) Content {
+			return Content{Data: mapNode{Value: content.Data.(int)}}
+		}, []interface{}{}).MapTo([]mapNodeDTO{})
+		Expect(s.Interface()).To(Equal([]mapNodeDTO{{Value: 0}, {Value: 1}, {Value: 2}}))
+		Expect(s.Err()).To(BeNil())
+	})
+	It("should fail in strict mode for unmapped fields", func() {
+		s := Of(users).MapToStrict([]mapUserDTO{})
+		Expect(s.Err()).To(BeNil())
+		Expect(s.Count()).To(Equal(2))
+
+		type extra struct {
+			Name  string
+			Phone string
+		}
+		s = Of(users).MapToStrict([]extra{})
+		Expect(s.Err()).To(MatchError(ContainSubstring("Phone")))
+		Expect(s.Interface()).To(Equal([]extra{}))
+
+		Expect(Of(users).MapTo([]extra{}).Interface()).To(Equal([]extra{{Name: "ali"}, {Name: "veli"}}))
+
+		s = Of([]interface{}{users[0], mapNode{Value: 1}}).MapToStrict([]extra{})
+		Expect(s.Interface()).To(Equal([]extra{}))
+		Expect(s.Err()).To(MatchError(ContainSubstring("Phone")))
+	})
+	It("should fail for incompatible fields", func() {
+		type wrong struct {
+			Name int
+		}
+		s := Of(users).MapTo([]wrong{})
+		Expect(s.Err()).To(MatchError(ContainSubstring("Name")))
+		Expect(s.Interface()).To(Equal([]wrong{}))
+
+		s = Of([]interface{}{mapNode{Value: 1}, "x"}).MapTo([]mapNodeDTO{})
+		Expect(s.Interface()).To(Equal([]mapNodeDTO{{Value: 1}}))
+		Expect(s.Err()).To(HaveOccurred())
+	})
+	It("should panic for invalid newType", func() {
+		Expect(func() { Of(users).MapTo(mapUserDTO{}) }).To(Panic())
+	})
+})
@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// value at dot separated path of Data like "user.address.city" or "items.0.price".
+// maps with string keys, slices and struct fields are walked, nil when path does not exist
+func (c Content) Get(path string) interface{} {
+	v, _ := lookup(c.Data, path)
+	return v
+}
+
+// path exists even if its value is nil
+func (c Content) Has(path string) bool {
+	_, ok := lookup(c.Data, path)
+	return ok
+}
+
+// string at path, default or empty string when it is missing or not a string
+func (c Content) GetString(path string, def ...string) string {
+	if v, ok := lookup(c.Data, path); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+			return rv.String()
+		}
+	}
+
+	if len(def) > 0 {
+		return def[0]
+	}
+	return ""
+}
+
+// integer at path, json numbers without fraction are accepted.
+// default or zero when it is missing or not an integer
+func (c Content) GetInt(path string, def ...int) int {
+	if v, ok := lookup(c.Data, path); ok {
+		if i, ok := toInt(v); ok {
+			return i
+		}
+	}
+
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
+
+// number at path, default or zero when it is missing or not a number
+func (c Content) GetFloat(path string, def ...float64) float64 {
+	if v, ok := lookup(c.Data, path); ok {
+		if f, ok := toNumber(v); ok {
+			return f
+		}
+	}
+
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
+
+// keep elements whose value at path exists and matches
+func pathFilter(path string, f func(interface{}) bool) Filter {
+	return func(content Content) bool {
+		v, ok := lookup(content.Data, path)
+		return ok && f(v)
+	}
+}
+
+func hasPath(path string) Filter {
+	return func(content Content) bool {
+		return content.Has(path)
+	}
+}
+
+// value is wrapped in one element slice, so slice values are not spread by Map
+func getPath(path string) Action {
+	return func(content Content) Content {
+		return Content{Data: []interface{}{content.Get(path)}}
+	}
+}
+
+// empty path is data itself, "a[0]" is same with "a.0" and negative index is from end
+func lookup(data interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return data, true
+	}
+
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	v := reflect.ValueOf(data)
+	for _, name := range strings.Split(path, ".") {
+		var ok bool
+		if v, ok = indirect(v); !ok {
+			return nil, false
+		}
+
+		var key interface{} = name
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			i, err := strconv.Atoi(name)
+			if err != nil {
+				return nil, false
+			}
+			key = i
+		}
+
+		if v, ok = childOf(v, key); !ok {
+			return nil, false
+		}
+	}
+
+	return valueOf(v), true
+}
+
+func toInt(v interface{}) (int, bool) {
+	if n, ok := v.(json.Number); ok {
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		return int(f), f == math.Trunc(f) && !math.IsInf(f, 0)
+	}
+
+	return 0, false
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type pathUser struct {
+	Name    string
+	Address *pathAddress
+}
+
+type pathAddress struct {
+	City string
+}
+
+var _ = Describe("Test Path", func() {
+	var records []map[string]interface{}
+
+	BeforeEach(func() {
+		data := `[
+			{"id": 1, "user": {"name": "ali", "address": {"city": "istanbul"}}, "items": [{"price": 10.5}, {"price": 3}]},
+			{"id": 2, "user": {"name": "veli"}, "items": []},
+			{"id": 3.5, "user": null}
+		]`
+		Expect(json.Unmarshal([]byte(data), &records)).To(BeNil())
+	})
+
+	Describe("Content", func() {
+		It("should get nested values", func() {
+			c := Content{Data: records[0]}
+			Expect(c.Get("user.address.city")).To(Equal("istanbul"))
+			Expect(c.Get("items.1.price")).To(Equal(3.0))
+			Expect(c.Get("items[0].price")).To(Equal(10.5))
+			Expect(c.Get("user.phone")).To(BeNil())
+			Expect(c.Get("items.5.price")).To(BeNil())
+			Expect(c.Get("id.x")).To(BeNil())
+		})
+		It("should check path", func() {
+			Expect(Content{Data: records[2]}.Has("user")).To(BeTrue())
+			Expect(Content{Data: records[2]}.Has("user.name")).To(BeFalse())
+		})
+		It("should get typed values with defaults", func() {
+			c := Content{Data: records[0]}
+			Expect(c.GetString("user.name")).To(Equal("ali"))
+			Expect(c.GetString("id", "none")).To(Equal("none"))
+			Expect(c.GetString("user.address.zip")).To(Equal(""))
+			Expect(c.GetInt("id")).To(Equal(1))
+			Expect(c.GetInt("items.0.price", -1)).To(Equal(-1))
+			Expect(Content{Data: records[2]}.GetInt("id", 7)).To(Equal(7))
+			Expect(c.GetFloat("items.0.price")).To(Equal(10.5))
+			Expect(c.GetFloat("user", 1)).To(Equal(1.0))
+		})
+		It("should walk structs and json numbers", func() {
+			c := Content{Data: pathUser{Name: "ali", Address: &pathAddress{City: "izmir"}}}
+			Expect(c.GetString("Address.City")).To(Equal("izmir"))
+			Expect(Content{Data: pathUser{}}.Has("Address.City")).To(BeFalse())
+
+			decoder := json.NewDecoder(strings.NewReader(`{"n": 42}`))
+			decoder.UseNumber()
+			var v map[string]interface{}
+			Expect(decoder.Decode(&v)).To(BeNil())
+			Expect(Content{Data: v}.GetInt("n")).To(Equal(42))
+		})
+	})
+	Describe("FilterPath", func() {
+		It("should filter by value at path", func() {
+			v := Of(records).FilterPath("user.name", func(v interface{}) bool {
+				return v == "veli"
+			}).Interface().([]map[string]interface{})
+			Expect(v).To(HaveLen(1))
+			Expect(v[0]["id"]).To(Equal(2.0))
+		})
+		It("should filter lazy streams", func() {
+			data := "{\"a\":{\"b\":1}}\n{\"a\":{}}\n{\"a\":{\"b\":2}}\n"
+			count := OfNDJSON(strings.NewReader(data), map[string]interface{}{}).
+				FilterPath("a.b", func(v interface{}) bool { return true }).
+				Count()
+			Expect(count).To(Equal(2))
+		})
+	})
+	Describe("Pluck", func() {
+		It("should pluck existing values", func() {
+			v := Of(records).Pluck("user.name").Interface()
+			Expect(v).To(Equal([]interface{}{"ali", "veli"}))
+		})
+		It("should pluck map values", func() {
+			v := Of(map[string]pathUser{"a": {Name: "ali"}}).Pluck("Name").Interface()
+			Expect(v).To(Equal([]interface{}{"ali"}))
+		})
+		It("should keep slice values as one element", func() {
+			docs := []map[string]interface{}{
+				{"tags": []interface{}{"a", "b"}},
+				{"tags": []interface{}{"c"}},
+				{"name": "x"},
+			}
+			v := Of(docs).Pluck("tags").Interface()
+			Expect(v).To(Equal([]interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}}))
+
+			v = Of(records).Pluck("items").Interface()
+			Expect(v).To(HaveLen(2))
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package stream
+
+import "sync"
+
+// state shared by all stages of a stream, it is passed to the stream Map returns
+type pipeline struct {
+	mutex         sync.Mutex
+	err           error
+	violations    []Violation
+	violationSink ViolationSink
+	deadLetter    DeadLetterSink
+	stages        []*stage
+	rate          *RateLimit
+	breaker       *CircuitBreaker
+}
+
+func newPipeline() *pipeline {
+	return &pipeline{}
+}
+
+// keep first error
+func (p *pipeline) fail(err error) {
+	if err == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// first error of the stream, source read errors are set when a terminal operation reads the source
+func (p *pipeline) Err() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.err
+}
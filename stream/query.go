@@ -0,0 +1,845 @@
+package stream
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// runs SQL like query over slice or map data and returns rows in a stream of newType.
+//
+//	SELECT Name, Score * 2 AS double FROM . WHERE Id > 3 GROUP BY Name HAVING COUNT(*) > 1 ORDER BY Name DESC LIMIT 5 OFFSET 1
+//
+// FROM is . for data itself or a path like orders.items. Expressions are FilterExpr expressions,
+// COUNT(*), COUNT, SUM, AVG, MIN and MAX aggregate rows of groups, all rows are one group without GROUP BY.
+// ORDER BY can use column names. newType is []map[string]interface{} by default, struct fields are
+// set by column name case insensitive, other types get first column.
+// query errors are set to Err of an empty stream with their position, evaluation errors are set when rows are pulled
+func Query(data interface{}, query string, newType ...interface{}) IStream {
+	typeOf := reflect.TypeOf([]map[string]interface{}{})
+	if len(newType) > 0 {
+		typeOf = reflect.TypeOf(newType[0])
+	}
+	if typeOf.Kind() != reflect.Slice {
+		panic("newType should be slice")
+	}
+
+	p := newPipeline()
+	q, err := parseQuery(query)
+	if err != nil {
+		p.fail(err)
+		return ofList(p, reflect.MakeSlice(typeOf, 0, 0))
+	}
+
+	return q.run(p, data, typeOf)
+}
+
+type queryColumn struct {
+	name string
+	expr *Expr
+}
+
+type queryOrder struct {
+	name string
+	expr *Expr
+	desc bool
+}
+
+// arg is nil for COUNT(*)
+type queryAggregate struct {
+	name string
+	arg  *Expr
+}
+
+type compiledQuery struct {
+	src        string
+	star       bool
+	columns    []queryColumn
+	from       string
+	where      *Expr
+	groupBy    []queryColumn
+	having     *Expr
+	orderBy    []queryOrder
+	limit      int
+	offset     int
+	aggregates []queryAggregate
+}
+
+var queryClauses = []string{"SELECT", "FROM", "WHERE", "GROUP BY", "HAVING", "ORDER BY", "LIMIT", "OFFSET"}
+
+var aggregateNames = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+func parseQuery(src string) (*compiledQuery, error) {
+	q := &compiledQuery{src: src, limit: -1}
+
+	clauses, err := q.split()
+	if err != nil {
+		return nil, err
+	}
+
+	selectClause, ok := clauses["SELECT"]
+	if !ok {
+		return nil, q.errorf(0, "expected SELECT")
+	}
+	fromClause, ok := clauses["FROM"]
+	if !ok {
+		return nil, q.errorf(len(src), "expected FROM")
+	}
+
+	q.from = strings.TrimSpace(fromClause.text)
+	if q.from == "" {
+		return nil, q.errorf(fromClause.pos, "expected source")
+	}
+
+	if strings.TrimSpace(selectClause.text) == "*" {
+		q.star = true
+	} else {
+		for _, item := range splitTopLevel(selectClause.text, selectClause.pos) {
+			column, err := q.column(item, true)
+			if err != nil {
+				return nil, err
+			}
+			q.columns = append(q.columns, column)
+		}
+	}
+
+	if clause, ok := clauses["WHERE"]; ok {
+		if q.where, err = q.compile(clause.text, clause.pos, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if clause, ok := clauses["GROUP BY"]; ok {
+		for _, item := range splitTopLevel(clause.text, clause.pos) {
+			column, err := q.column(item, false)
+			if err != nil {
+				return nil, err
+			}
+			q.groupBy = append(q.groupBy, column)
+		}
+	}
+
+	if clause, ok := clauses["HAVING"]; ok {
+		if q.having, err = q.compile(clause.text, clause.pos, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if clause, ok := clauses["ORDER BY"]; ok {
+		for _, item := range splitTopLevel(clause.text, clause.pos) {
+			order := queryOrder{}
+			text := strings.TrimSpace(item.text)
+			if words := strings.Fields(text); len(words) > 1 {
+				last := strings.ToUpper(words[len(words)-1])
+				if last == "DESC" || last == "ASC" {
+					order.desc = last == "DESC"
+					text = strings.TrimSpace(text[:len(text)-len(last)])
+				}
+			}
+
+			order.name = text
+			if order.expr, err = q.compile(text, item.pos+strings.Index(item.text, text), true); err != nil {
+				return nil, err
+			}
+			q.orderBy = append(q.orderBy, order)
+		}
+	}
+
+	if clause, ok := clauses["LIMIT"]; ok {
+		if q.limit, err = q.int(clause); err != nil {
+			return nil, err
+		}
+	}
+	if clause, ok := clauses["OFFSET"]; ok {
+		if q.offset, err = q.int(clause); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+func (q *compiledQuery) errorf(pos int, format string, args ...interface{}) error {
+	return &ExprError{Expr: q.src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type queryPart struct {
+	text string
+	pos  int
+}
+
+// clauses by keyword, keywords are case insensitive and should be in order
+func (q *compiledQuery) split() (map[string]queryPart, error) {
+	type match struct {
+		keyword string
+		pos     int
+		end     int
+	}
+
+	var matches []match
+	scanTopLevel(q.src, func(i int) {
+		if i > 0 && isWordChar(q.src[i-1]) {
+			return
+		}
+		for _, keyword := range queryClauses {
+			if end, ok := matchKeyword(q.src, i, keyword); ok {
+				matches = append(matches, match{keyword: keyword, pos: i, end: end})
+				return
+			}
+		}
+	})
+
+	clauses := map[string]queryPart{}
+	lastOrder := -1
+	for i, m := range matches {
+		order := indexOf(queryClauses, m.keyword)
+		if order <= lastOrder {
+			return nil, q.errorf(m.pos, "unexpected %s", m.keyword)
+		}
+		lastOrder = order
+
+		end := len(q.src)
+		if i+1 < len(matches) {
+			end = matches[i+1].pos
+		}
+		clauses[m.keyword] = queryPart{text: q.src[m.end:end], pos: m.end}
+	}
+
+	if len(matches) > 0 && strings.TrimSpace(q.src[:matches[0].pos]) != "" {
+		return nil, q.errorf(0, "expected SELECT")
+	}
+
+	return clauses, nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// keyword at i followed by a non word character, spaces in keyword match any white space
+func matchKeyword(src string, i int, keyword string) (int, bool) {
+	for n, word := range strings.Split(keyword, " ") {
+		for n > 0 && i < len(src) && unicode.IsSpace(rune(src[i])) {
+			i++
+		}
+		if len(src) < i+len(word) || !strings.EqualFold(src[i:i+len(word)], word) {
+			return 0, false
+		}
+		i += len(word)
+	}
+
+	if i < len(src) && isWordChar(src[i]) {
+		return 0, false
+	}
+
+	return i, true
+}
+
+// calls f for every index outside of strings, brackets, braces and parentheses
+func scanTopLevel(src string, f func(i int)) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0:
+			f(i)
+		}
+	}
+}
+
+// splits src by sep outside of quotes, brackets, braces and parentheses like `a, f(b, c), 'd,e'`
+func SplitTopLevel(src string, sep byte) []string {
+	var parts []string
+	st := 0
+	scanTopLevel(src, func(i int) {
+		if src[i] == sep {
+			parts = append(parts, src[st:i])
+			st = i + 1
+		}
+	})
+
+	return append(parts, src[st:])
+}
+
+// comma separated parts with their position
+func splitTopLevel(text string, pos int) []queryPart {
+	var parts []queryPart
+	for _, part := range SplitTopLevel(text, ',') {
+		parts = append(parts, queryPart{text: part, pos: pos})
+		pos += len(part) + 1
+	}
+
+	return parts
+}
+
+// expression with optional AS alias, name is alias or expression text
+func (q *compiledQuery) column(part queryPart, aggregates bool) (queryColumn, error) {
+	text := part.text
+	name := ""
+
+	alias := -1
+	scanTopLevel(text, func(i int) {
+		if i > 0 && unicode.IsSpace(rune(text[i-1])) {
+			if _, ok := matchKeyword(text, i, "AS"); ok {
+				alias = i
+			}
+		}
+	})
+	if alias >= 0 {
+		name = strings.TrimSpace(text[alias+2:])
+		text = text[:alias]
+	}
+
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return queryColumn{}, q.errorf(part.pos, "expected expression")
+	}
+	if name == "" {
+		name = trimmed
+	}
+
+	expr, err := q.compile(text, part.pos, aggregates)
+	if err != nil {
+		return queryColumn{}, err
+	}
+
+	return queryColumn{name: name, expr: expr}, nil
+}
+
+// aggregate calls are replaced by placeholders of same length so error positions do not change
+func (q *compiledQuery) compile(text string, pos int, aggregates bool) (*Expr, error) {
+	if aggregates {
+		var err error
+		if text, err = q.replaceAggregates(text, pos); err != nil {
+			return nil, err
+		}
+	}
+
+	expr, err := CompileExpr(text)
+	if err != nil {
+		if exprErr, ok := err.(*ExprError); ok {
+			return nil, q.errorf(pos+exprErr.Pos, "%s", exprErr.Msg)
+		}
+		return nil, err
+	}
+
+	return expr, nil
+}
+
+func (q *compiledQuery) replaceAggregates(text string, pos int) (string, error) {
+	b := []byte(text)
+
+	var err error
+	scanTopLevelIdents(text, func(st, end int) {
+		name := strings.ToUpper(text[st:end])
+		if err != nil || !aggregateNames[name] || end >= len(text) || text[end] != '(' {
+			return
+		}
+
+		closing := matchingParen(text, end)
+		if closing < 0 {
+			err = q.errorf(pos+end, "expected )")
+			return
+		}
+
+		arg := text[end+1 : closing]
+		if (name == "MIN" || name == "MAX") && len(splitTopLevel(arg, 0)) > 1 {
+			// min and max functions of expressions
+			return
+		}
+
+		aggregate := queryAggregate{name: name}
+		if strings.TrimSpace(arg) == "*" {
+			if name != "COUNT" {
+				err = q.errorf(pos+end+1, "* is only valid in COUNT")
+				return
+			}
+		} else if aggregate.arg, err = q.compile(arg, pos+end+1, false); err != nil {
+			return
+		}
+
+		placeholder := "_a" + strconv.Itoa(len(q.aggregates))
+		q.aggregates = append(q.aggregates, aggregate)
+		for i := st; i <= closing; i++ {
+			b[i] = ' '
+		}
+		copy(b[st:], placeholder)
+	})
+
+	return string(b), err
+}
+
+// identifiers outside of strings, nested calls are also visited
+func scanTopLevelIdents(text string, f func(st, end int)) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case (c == '_' || unicode.IsLetter(rune(c))) && (i == 0 || !isWordChar(text[i-1])):
+			st := i
+			for i < len(text) && isWordChar(text[i]) && text[i] != '.' {
+				i++
+			}
+			f(st, i)
+			i--
+		}
+	}
+}
+
+func matchingParen(text string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func (q *compiledQuery) int(part queryPart) (int, error) {
+	i, err := strconv.Atoi(strings.TrimSpace(part.text))
+	if err != nil || i < 0 {
+		return 0, q.errorf(part.pos, "expected non negative integer")
+	}
+
+	return i, nil
+}
+
+type queryRow struct {
+	env    Content
+	values []interface{}
+	names  []string
+	keys   []interface{}
+}
+
+// rows go through Filter, Map, SortStableBy, Skip and Limit of a stream, grouping reads all rows first
+func (q *compiledQuery) run(p *pipeline, data interface{}, typeOf reflect.Type) IStream {
+	rows, err := q.source(data)
+	if err != nil {
+		p.fail(err)
+		return ofList(p, reflect.MakeSlice(typeOf, 0, 0))
+	}
+
+	var s IStream = ofList(p, reflect.ValueOf(rows))
+	if q.where != nil {
+		s = s.Filter(func(c Content) bool {
+			ok, err := q.where.Match(c.Data.(queryRow).env)
+			p.fail(err)
+			return ok
+		})
+	}
+
+	if len(q.groupBy) > 0 || len(q.aggregates) > 0 {
+		s = ofList(p, reflect.ValueOf(q.group(p, s.(*lazy).contents())))
+	}
+
+	s = s.Map(q.project(p), []queryRow{})
+	if len(q.orderBy) > 0 {
+		s = s.SortStableBy(q.compare)
+	}
+
+	s = s.Skip(q.offset)
+	if q.limit == 0 {
+		s = s.TakeWhile(none)
+	} else {
+		s = s.Limit(q.limit)
+	}
+
+	return s.Map(func(c Content) Content {
+		item, err := rowItem(c.Data.(queryRow), typeOf.Elem())
+		p.fail(err)
+		// one element slice, so slice items are not spread
+		return Content{Data: reflect.Append(reflect.MakeSlice(typeOf, 0, 1), item).Interface()}
+	}, reflect.MakeSlice(typeOf, 0, 0).Interface())
+}
+
+// elements of slice or values of map in key order
+func (q *compiledQuery) source(data interface{}) ([]queryRow, error) {
+	if q.from != "." {
+		v, ok := lookup(data, strings.TrimPrefix(q.from, "."))
+		if !ok {
+			return nil, q.errorf(strings.Index(q.src, q.from), "unknown source %s", q.from)
+		}
+		data = v
+	}
+
+	v, ok := indirect(reflect.ValueOf(data))
+	if !ok {
+		return nil, nil
+	}
+
+	rows := []queryRow{}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			rows = append(rows, queryRow{env: Content{Data: v.Index(i).Interface()}})
+		}
+	case reflect.Map:
+		for _, key := range sortedMapKeys(v) {
+			rows = append(rows, queryRow{env: Content{Key: key.Interface(), Data: v.MapIndex(key).Interface()}})
+		}
+	default:
+		return nil, q.errorf(strings.Index(q.src, q.from), "source %s should be slice or map", q.from)
+	}
+
+	return rows, nil
+}
+
+// one row per group in order of first element. row has fields of first element,
+// group columns by name and aggregates as placeholders
+func (q *compiledQuery) group(p *pipeline, contents []Content) []queryRow {
+	type group struct {
+		first  Content
+		keys   []interface{}
+		values [][]interface{}
+		count  int
+	}
+
+	var groups []*group
+	byKey := map[string]*group{}
+	for _, content := range contents {
+		row := content.Data.(queryRow).env
+		keys := make([]interface{}, len(q.groupBy))
+		for i, column := range q.groupBy {
+			v, err := column.expr.Eval(row)
+			p.fail(err)
+			keys[i] = v
+		}
+
+		id := fmt.Sprintf("%#v", keys)
+		g, ok := byKey[id]
+		if !ok {
+			g = &group{first: row, keys: keys, values: make([][]interface{}, len(q.aggregates))}
+			byKey[id] = g
+			groups = append(groups, g)
+		}
+
+		g.count++
+		for i, aggregate := range q.aggregates {
+			if aggregate.arg == nil {
+				continue
+			}
+			v, err := aggregate.arg.Eval(row)
+			p.fail(err)
+			g.values[i] = append(g.values[i], v)
+		}
+	}
+
+	// aggregate query without group by has one row
+	if len(groups) == 0 && len(q.groupBy) == 0 {
+		groups = append(groups, &group{values: make([][]interface{}, len(q.aggregates))})
+	}
+
+	result := []queryRow{}
+	for _, g := range groups {
+		env := map[string]interface{}{}
+		names, values := rowFields(g.first.Data)
+		for i, name := range names {
+			env[name] = values[i]
+		}
+		for i, column := range q.groupBy {
+			env[column.name] = g.keys[i]
+		}
+		for i, aggregate := range q.aggregates {
+			v, err := aggregateOf(aggregate, g.values[i], g.count)
+			if err != nil {
+				p.fail(q.errorf(0, "%s: %v", aggregate.name, err))
+			}
+			env["_a"+strconv.Itoa(i)] = v
+		}
+
+		if q.having != nil {
+			ok, err := q.having.Match(Content{Key: g.first.Key, Data: env})
+			p.fail(err)
+			if !ok {
+				continue
+			}
+		}
+
+		result = append(result, queryRow{env: Content{Key: g.first.Key, Data: env}})
+	}
+
+	return result
+}
+
+func aggregateOf(aggregate queryAggregate, values []interface{}, count int) (interface{}, error) {
+	if aggregate.arg == nil {
+		return int64(count), nil
+	}
+
+	var present []interface{}
+	for _, v := range values {
+		if v != nil {
+			present = append(present, v)
+		}
+	}
+
+	switch aggregate.name {
+	case "COUNT":
+		return int64(len(present)), nil
+	case "MIN", "MAX":
+		var edge interface{}
+		for _, v := range present {
+			c := compareSortValues(v, edge)
+			if edge == nil || (aggregate.name == "MIN" && c < 0) || (aggregate.name == "MAX" && c > 0) {
+				edge = v
+			}
+		}
+		return edge, nil
+	}
+
+	if len(present) == 0 {
+		return nil, nil
+	}
+
+	var isum int64
+	var fsum float64
+	integer := true
+	for _, v := range present {
+		f, ok := toNumber(v)
+		if !ok {
+			return nil, fmt.Errorf("%v is not number", v)
+		}
+		if i, ok := v.(int64); ok {
+			isum += i
+		} else {
+			integer = false
+		}
+		fsum += f
+	}
+
+	if aggregate.name == "AVG" {
+		return fsum / float64(len(present)), nil
+	}
+	if integer {
+		return isum, nil
+	}
+
+	return fsum, nil
+}
+
+// values of columns and keys of ORDER BY, keys use column names first
+func (q *compiledQuery) project(p *pipeline) Action {
+	return func(c Content) Content {
+		row := c.Data.(queryRow)
+		if q.star {
+			row.names, row.values = rowFields(row.env.Data)
+		}
+		for _, column := range q.columns {
+			v, err := column.expr.Eval(row.env)
+			p.fail(err)
+			row.names = append(row.names, column.name)
+			row.values = append(row.values, v)
+		}
+
+		for _, order := range q.orderBy {
+			if j := indexOf(row.names, order.name); j >= 0 {
+				row.keys = append(row.keys, row.values[j])
+				continue
+			}
+			v, err := order.expr.Eval(row.env)
+			p.fail(err)
+			row.keys = append(row.keys, v)
+		}
+
+		return Content{Key: c.Key, Data: row}
+	}
+}
+
+// positive result puts c1 first like other Compare functions
+func (q *compiledQuery) compare(c1, c2 Content) int {
+	k1, k2 := c1.Data.(queryRow).keys, c2.Data.(queryRow).keys
+	for i, order := range q.orderBy {
+		c := compareSortValues(k1[i], k2[i])
+		if c == 0 {
+			continue
+		}
+		if order.desc {
+			return c
+		}
+		return -c
+	}
+
+	return 0
+}
+
+// nil first, then numbers, strings, bools and others by text
+func compareSortValues(a, b interface{}) int {
+	rank := func(v interface{}) int {
+		if v == nil {
+			return 0
+		}
+		if _, ok := toNumber(v); ok {
+			return 1
+		}
+		switch v.(type) {
+		case string:
+			return 2
+		case bool:
+			return 3
+		}
+		return 4
+	}
+
+	ra, rb := rank(a), rank(b)
+	if ra != rb {
+		return ra - rb
+	}
+
+	switch ra {
+	case 0:
+		return 0
+	case 1:
+		af, _ := toNumber(a)
+		bf, _ := toNumber(b)
+		return compareFloat(af, bf)
+	case 2:
+		return strings.Compare(a.(string), b.(string))
+	case 3:
+		if a == b {
+			return 0
+		}
+		if a == false {
+			return -1
+		}
+		return 1
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// exported struct fields or string map entries in key order, other values are one field named _
+func rowFields(data interface{}) ([]string, []interface{}) {
+	v, ok := indirect(reflect.ValueOf(data))
+	if !ok {
+		return nil, nil
+	}
+
+	var names []string
+	var values []interface{}
+	switch {
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.PkgPath == "" {
+				names = append(names, field.Name)
+				values = append(values, normalize(v.Field(i).Interface()))
+			}
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		for _, key := range sortedMapKeys(v) {
+			names = append(names, key.String())
+			values = append(values, normalize(v.MapIndex(key).Interface()))
+		}
+	default:
+		names = append(names, "_")
+		values = append(values, normalize(data))
+	}
+
+	return names, values
+}
+
+// map and struct elements get columns by name, other elements get first column
+func rowItem(row queryRow, elem reflect.Type) (reflect.Value, error) {
+	switch {
+	case elem.Kind() == reflect.Interface || (elem.Kind() == reflect.Map && elem.Key().Kind() == reflect.String):
+		mapType := elem
+		if elem.Kind() == reflect.Interface {
+			mapType = reflect.TypeOf(map[string]interface{}{})
+		}
+		item := reflect.MakeMap(mapType)
+		for i, name := range row.names {
+			v, err := convertValue(row.values[i], mapType.Elem())
+			if err != nil {
+				return reflect.Zero(elem), err
+			}
+			item.SetMapIndex(reflect.ValueOf(name).Convert(mapType.Key()), valueFor(v, mapType.Elem()))
+		}
+		return item.Convert(elem), nil
+	case elem.Kind() == reflect.Struct:
+		item := reflect.New(elem).Elem()
+		for i, name := range row.names {
+			field, ok := elem.FieldByNameFunc(func(n string) bool {
+				return strings.EqualFold(n, name)
+			})
+			if !ok || field.PkgPath != "" {
+				continue
+			}
+			v, err := convertValue(row.values[i], field.Type)
+			if err != nil {
+				return item, fmt.Errorf("column %s: %v", name, err)
+			}
+			item.FieldByIndex(field.Index).Set(valueFor(v, field.Type))
+		}
+		return item, nil
+	}
+
+	if len(row.values) == 0 {
+		return reflect.Zero(elem), nil
+	}
+
+	v, err := convertValue(row.values[0], elem)
+	if err != nil {
+		return reflect.Zero(elem), err
+	}
+
+	return valueFor(v, elem), nil
+}
+
+// nil is zero value of type
+func valueFor(v interface{}, t reflect.Type) reflect.Value {
+	if v == nil {
+		return reflect.Zero(t)
+	}
+
+	return reflect.ValueOf(v)
+}
@@ -0,0 +1,136 @@
+package stream
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type queryEmployee struct {
+	Id     int
+	Name   string
+	Dept   string
+	Salary float64
+}
+
+type queryDeptRow struct {
+	Dept  string
+	Count int
+	Total float64
+}
+
+var _ = Describe("Test Query", func() {
+	employees := []queryEmployee{
+		{Id: 1, Name: "ali", Dept: "dev", Salary: 100},
+		{Id: 2, Name: "veli", Dept: "ops", Salary: 80},
+		{Id: 3, Name: "ayse", Dept: "dev", Salary: 120},
+		{Id: 4, Name: "fatma", Dept: "hr", Salary: 90},
+		{Id: 5, Name: "mehmet", Dept: "dev", Salary: 110},
+		{Id: 6, Name: "zeynep", Dept: "ops", Salary: 70},
+	}
+
+	It("should select, filter, order and limit", func() {
+		s := Query(employees, "SELECT Name, Id FROM . WHERE Id > 3 ORDER BY Name DESC LIMIT 2")
+		Expect(s.Err()).To(BeNil())
+		Expect(s.Interface()).To(Equal([]map[string]interface{}{
+			{"Name": "zeynep", "Id": int64(6)},
+			{"Name": "mehmet", "Id": int64(5)},
+		}))
+	})
+	It("should write into given slice type", func() {
+		v := Query(employees, "select Name, Salary * 2 as salary from . where Dept = 'ops' order by Id", []queryEmployee{}).Interface()
+		Expect(v).To(Equal([]queryEmployee{{Name: "veli", Salary: 160}, {Name: "zeynep", Salary: 140}}))
+
+		v = Query(employees, "SELECT Name FROM . WHERE Salary >= 110 ORDER BY Salary", []string{}).Interface()
+		Expect(v).To(Equal([]string{"mehmet", "ayse"}))
+	})
+	It("should select all fields", func() {
+		v := Query(employees, "SELECT * FROM . WHERE Id == 1").Interface()
+		Expect(v).To(Equal([]map[string]interface{}{
+			{"Id": int64(1), "Name": "ali", "Dept": "dev", "Salary": 100.0},
+		}))
+	})
+	It("should group with aggregates", func() {
+		query := `SELECT Dept, COUNT(*) AS Count, SUM(Salary) AS Total
+			FROM .
+			GROUP BY Dept
+			HAVING COUNT(*) > 1
+			ORDER BY Total DESC`
+		s := Query(employees, query, []queryDeptRow{})
+		Expect(s.Err()).To(BeNil())
+		Expect(s.Interface()).To(Equal([]queryDeptRow{
+			{Dept: "dev", Count: 3, Total: 330},
+			{Dept: "ops", Count: 2, Total: 150},
+		}))
+	})
+	It("should aggregate all rows without group by", func() {
+		v := Query(employees, "SELECT COUNT(*) AS n, AVG(Salary) AS avg, MIN(Name) AS first, MAX(Salary) - MIN(Salary) AS spread FROM .").Interface()
+		Expect(v).To(Equal([]map[string]interface{}{
+			{"n": int64(6), "avg": 95.0, "first": "ali", "spread": 50.0},
+		}))
+
+		v = Query(employees, "SELECT COUNT(*) FROM . WHERE Id > 10", []int{}).Interface()
+		Expect(v).To(Equal([]int{0}))
+	})
+	It("should query maps and paths", func() {
+		data := map[string]interface{}{
+			"orders": []interface{}{
+				map[string]interface{}{"id": 1.0, "total": 30.0},
+				map[string]interface{}{"id": 2.0, "total": 10.0},
+			},
+		}
+		v := Query(data, "SELECT id FROM orders ORDER BY total LIMIT 1 OFFSET 1", []int{}).Interface()
+		Expect(v).To(Equal([]int{1}))
+
+		v = Query(map[string]int{"a": 3, "b": 1, "c": 2}, "SELECT _key AS key FROM . WHERE _ > 1 ORDER BY _", []string{}).Interface()
+		Expect(v).To(Equal([]string{"c", "a"}))
+
+		v = Query(data, "SELECT id FROM orders LIMIT 0", []int{}).Interface()
+		Expect(v).To(Equal([]int{}))
+	})
+	It("should keep slice columns as one row", func() {
+		data := []map[string]interface{}{{"tags": []interface{}{"a", "b"}}, {"tags": []interface{}{"c"}}}
+		v := Query(data, "SELECT tags FROM .", [][]interface{}{}).Interface()
+		Expect(v).To(Equal([][]interface{}{{"a", "b"}, {"c"}}))
+	})
+	It("should chain stream functions", func() {
+		v := Query(employees, "SELECT Name FROM . ORDER BY Id", []string{}).
+			Filter(func(content Content) bool {
+				return len(content.Data.(string)) > 4
+			}).
+			Interface()
+		Expect(v).To(Equal([]string{"fatma", "mehmet", "zeynep"}))
+	})
+	It("should report errors with position", func() {
+		cases := map[string]int{
+			"Name FROM .":                          0,
+			"SELECT Name":                          11,
+			"SELECT Name FROM . WHERE Id >":        29,
+			"SELECT Name FROM . LIMIT x":           24,
+			"SELECT FROM . SELECT Name":            14,
+			"SELECT SUM(*) FROM .":                 11,
+			"SELECT Name FROM . ORDER BY Id +":     32,
+			"SELECT Name FROM . WHERE Name == 'a'": -1,
+		}
+		for query, pos := range cases {
+			s := Query(employees, query)
+			if pos < 0 {
+				Expect(s.Err()).To(BeNil(), query)
+				continue
+			}
+			Expect(s.Err()).To(HaveOccurred(), query)
+			Expect(s.Err().(*ExprError).Pos).To(Equal(pos), query)
+			Expect(s.Interface()).To(Equal([]map[string]interface{}{}))
+		}
+	})
+	It("should split outside of quotes and brackets", func() {
+		Expect(SplitTopLevel(`a, f(b, c), 'd,e', [1, 2], {x, y}`, ',')).To(Equal([]string{"a", " f(b, c)", " 'd,e'", " [1, 2]", " {x, y}"}))
+		Expect(SplitTopLevel(`name: "a:b"`, ':')).To(Equal([]string{"name", ` "a:b"`}))
+		Expect(SplitTopLevel("", ',')).To(Equal([]string{""}))
+	})
+	It("should set evaluation error", func() {
+		// rows are evaluated by terminal function like FilterExpr
+		s := Query(employees, "SELECT Name FROM . WHERE Unknown > 1")
+		Expect(s.Interface()).To(Equal([]map[string]interface{}{}))
+		Expect(s.Err()).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// token bucket of Map stages after RateLimit, every stage has its own bucket shared by its workers
+type RateLimit struct {
+	// operations per Interval
+	N        int
+	Interval time.Duration
+	// operations that can run without waiting after an idle time, default is 1
+	Burst int
+	// default is system clock
+	Clock Clock
+}
+
+type bucket struct {
+	mutex  sync.Mutex
+	clock  Clock
+	rate   float64 // tokens per nanosecond
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (limit RateLimit) check() {
+	if limit.N <= 0 || limit.Interval <= 0 {
+		panic("rate limit should have positive N and Interval")
+	}
+}
+
+func newBucket(limit RateLimit) *bucket {
+	limit.check()
+
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	clock := clockOrSystem(limit.Clock)
+
+	return &bucket{
+		clock:  clock,
+		rate:   float64(limit.N) / float64(limit.Interval),
+		burst:  burst,
+		tokens: burst,
+		last:   clock.Now(),
+	}
+}
+
+// takes a token and sleeps until it is available. tokens can go below zero,
+// so waiting workers line up instead of racing for next token
+func (b *bucket) take() time.Duration {
+	b.mutex.Lock()
+	now := b.clock.Now()
+	if now.After(b.last) {
+		b.tokens += float64(now.Sub(b.last)) * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens--
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(math.Round(-b.tokens / b.rate))
+	}
+	b.mutex.Unlock()
+
+	if wait > 0 {
+		b.clock.Sleep(wait)
+	}
+
+	return wait
+}
+
+func (p *pipeline) setRateLimit(limit RateLimit) {
+	limit.check()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.rate = &limit
+}
+
+func (p *pipeline) rateLimitOf() *RateLimit {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.rate
+}
+
+// every call waits for a token of stage bucket when stream has a rate limit
+func (p *pipeline) limited(st *stage, f TryAction) TryAction {
+	limit := p.rateLimitOf()
+	if limit == nil {
+		return f
+	}
+
+	b := newBucket(*limit)
+	return func(c Content) (Content, error) {
+		atomic.AddInt64(&st.wait, int64(b.take()))
+		return f(c)
+	}
+}
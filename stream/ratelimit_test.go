@@ -0,0 +1,81 @@
+package stream
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test RateLimit", func() {
+	double := func(content Content) Content {
+		return Content{Data: content.Data.(int) * 2}
+	}
+
+	It("should wait for tokens after burst", func() {
+		clock := &fakeClock{}
+		s := Of([]int{1, 2, 3, 4, 5}).
+			RateLimit(RateLimit{N: 2, Interval: time.Second, Burst: 2, Clock: clock}).
+			Map(double, []int{})
+
+		Expect(s.Interface()).To(Equal([]int{2, 4, 6, 8, 10}))
+		half := 500 * time.Millisecond
+		Expect(clock.sleeps).To(Equal([]time.Duration{half, half, half}))
+		Expect(s.Trace()).To(Equal([]StageTrace{{Name: "Map 1", Wait: 3 * half}}))
+	})
+	It("should give every stage its own bucket", func() {
+		clock := &fakeClock{}
+		s := Of([]int{1, 2, 3}).
+			Map(double, []int{}).
+			RateLimit(RateLimit{N: 1, Interval: time.Second, Clock: clock}).
+			Map(double, []int{}).
+			TryMap(func(content Content) (Content, error) {
+				return content, nil
+			}, []int{})
+
+		Expect(s.Interface()).To(Equal([]int{4, 8, 12}))
+		// stages run element by element, so bucket of TryMap is refilled while Map waits
+		Expect(s.Trace()).To(Equal([]StageTrace{
+			{Name: "Map 1", Wait: 2 * time.Second},
+			{Name: "TryMap 2"},
+		}))
+	})
+	It("should take a token for every retry", func() {
+		clock := &fakeClock{}
+		calls := 0
+		s := Of([]int{1}).
+			RateLimit(RateLimit{N: 1, Interval: time.Second, Clock: clock}).
+			MapWithRetry(func(content Content) (Content, error) {
+				calls++
+				if calls < 3 {
+					return Content{}, errors.New("busy")
+				}
+				return content, nil
+			}, []int{}, RetryPolicy{Max: 3, Clock: clock})
+
+		Expect(s.Interface()).To(Equal([]int{1}))
+		Expect(s.Trace()).To(Equal([]StageTrace{{Name: "MapWithRetry 1", Retries: 2, Wait: 2 * time.Second}}))
+	})
+	It("should share bucket between workers", func() {
+		clock := &fakeClock{}
+		s := Range(0, 20, 1).
+			RateLimit(RateLimit{N: 10, Interval: time.Second, Clock: clock}).
+			Map(double, []int{}, 4)
+
+		Expect(s.Count()).To(Equal(20))
+		Expect(clock.sleeps).NotTo(BeEmpty())
+		Expect(s.Trace()[0].Wait).To(BeNumerically(">=", 100*time.Millisecond))
+	})
+	It("should wait with system clock", func() {
+		s := Of(map[string]int{"a": 1, "b": 2, "c": 3}).
+			RateLimit(RateLimit{N: 1, Interval: 5 * time.Millisecond}).
+			Map(double, []int{})
+
+		Expect(s.Count()).To(Equal(3))
+		Expect(s.Trace()[0].Wait).To(BeNumerically(">", 0))
+	})
+	It("should panic for invalid limit", func() {
+		Expect(func() { Of([]int{1}).RateLimit(RateLimit{N: 1}) }).To(Panic())
+	})
+})
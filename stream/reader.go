@@ -0,0 +1,36 @@
+package stream
+
+import (
+	"bufio"
+	"io"
+)
+
+// stream of lines without line endings, reader is read as elements are pulled
+func OfLines(r io.Reader) IStream {
+	return OfScanner(bufio.NewScanner(r))
+}
+
+// stream of scanner tokens, split function of scanner is used.
+// scan error is set to Err of stream when source is closed
+func OfScanner(sc *bufio.Scanner) IStream {
+	return OfIterator(&scannerIterator{scanner: sc}, []string{})
+}
+
+type scannerIterator struct {
+	scanner *bufio.Scanner
+	done    bool
+}
+
+func (it *scannerIterator) Next() (Content, bool) {
+	if it.done || !it.scanner.Scan() {
+		it.done = true
+		return Content{}, false
+	}
+
+	return Content{Data: it.scanner.Text()}, true
+}
+
+func (it *scannerIterator) Close() error {
+	it.done = true
+	return it.scanner.Err()
+}
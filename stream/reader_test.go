@@ -0,0 +1,91 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingReader struct {
+	data string
+	read bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("read failed")
+	}
+	r.read = true
+
+	return copy(p, r.data), nil
+}
+
+var _ = Describe("Test Reader", func() {
+	Describe("OfLines", func() {
+		It("should stream lines", func() {
+			v := OfLines(strings.NewReader("a\nbb\r\nccc\n")).Interface()
+			Expect(v).To(Equal([]string{"a", "bb", "ccc"}))
+		})
+		It("should filter and limit lines lazily", func() {
+			s := OfLines(strings.NewReader("1\n22\n333\n4444\n55555")).
+				Filter(func(content Content) bool {
+					return len(content.Data.(string))%2 == 1
+				}).
+				Limit(2)
+			Expect(s.Interface()).To(Equal([]string{"1", "333"}))
+			Expect(s.Err()).To(BeNil())
+		})
+		It("should set read error", func() {
+			s := OfLines(&failingReader{data: "a\nb\n"})
+			Expect(s.Count()).To(Equal(2))
+			Expect(s.Err()).To(MatchError("read failed"))
+		})
+	})
+	Describe("OfScanner", func() {
+		It("should use split function", func() {
+			sc := bufio.NewScanner(strings.NewReader("one two  three\nfour"))
+			sc.Split(bufio.ScanWords)
+			Expect(OfScanner(sc).Interface()).To(Equal([]string{"one", "two", "three", "four"}))
+		})
+		It("should set token error", func() {
+			sc := bufio.NewScanner(strings.NewReader("short\n" + strings.Repeat("x", 100) + "\n"))
+			sc.Buffer(make([]byte, 16), 16)
+			s := OfScanner(sc)
+			Expect(s.Interface()).To(Equal([]string{"short"}))
+			Expect(s.Err()).To(Equal(bufio.ErrTooLong))
+		})
+	})
+	Describe("Err", func() {
+		It("should be nil for slice sources", func() {
+			Expect(Of([]int{1, 2}).Err()).To(BeNil())
+		})
+		It("should be shared with mapped streams", func() {
+			s := OfLines(&failingReader{data: "1\n2\n"}).
+				Map(func(content Content) Content {
+					return Content{Data: len(content.Data.(string))}
+				}, []int{})
+			Expect(s.Interface()).To(Equal([]int{1, 1}))
+			Expect(s.Err()).To(MatchError("read failed"))
+		})
+		It("should be set after parallel stages", func() {
+			for i := 0; i < 20; i++ {
+				s := OfLines(&failingReader{data: "1\n22\n333\n"}).(*lazy)
+				s.filter(func(content Content) bool {
+					return len(content.Data.(string)) > 1
+				}, 4)
+				Expect(s.Count()).To(Equal(2))
+				Expect(s.Err()).To(MatchError("read failed"))
+
+				m := OfLines(&failingReader{data: "1\n22\n"}).(*lazy)
+				m.mapWith(func(content Content) Content {
+					return Content{Data: len(content.Data.(string))}
+				}, []int{}, 4)
+				Expect(m.Interface()).To(ConsistOf(1, 2))
+				Expect(m.Err()).To(MatchError("read failed"))
+			}
+		})
+	})
+})
@@ -0,0 +1,106 @@
+package stream
+
+import (
+	"reflect"
+	"sync"
+)
+
+type namedAction struct {
+	f       Action
+	newType interface{}
+}
+
+// named Filter, Action, Compare functions and element types that specs refer to.
+// it is safe for concurrent use
+type Registry struct {
+	mutex    sync.RWMutex
+	filters  map[string]Filter
+	actions  map[string]namedAction
+	compares map[string]Compare
+	types    map[string]reflect.Type
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		filters:  map[string]Filter{},
+		actions:  map[string]namedAction{},
+		compares: map[string]Compare{},
+		types:    map[string]reflect.Type{},
+	}
+}
+
+// same name replaces previous one
+func (r *Registry) RegisterFilter(name string, f Filter) *Registry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.filters[name] = f
+
+	return r
+}
+
+// newType is passed to Map like Map(f, newType)
+func (r *Registry) RegisterAction(name string, f Action, newType interface{}) *Registry {
+	kind := reflect.TypeOf(newType).Kind()
+	if kind != reflect.Slice && kind != reflect.Array && kind != reflect.Map {
+		panic("newType should be slice,array or map")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.actions[name] = namedAction{f: f, newType: newType}
+
+	return r
+}
+
+func (r *Registry) RegisterCompare(name string, f Compare) *Registry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.compares[name] = f
+
+	return r
+}
+
+// element type of json, ndjson and csv sources, sample is a value like User{}
+func (r *Registry) RegisterType(name string, sample interface{}) *Registry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.types[name] = reflect.TypeOf(sample)
+
+	return r
+}
+
+func (r *Registry) filter(name string) (Filter, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	f, ok := r.filters[name]
+	return f, ok
+}
+
+func (r *Registry) action(name string) (namedAction, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, ok := r.actions[name]
+	return a, ok
+}
+
+func (r *Registry) compare(name string) (Compare, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	f, ok := r.compares[name]
+	return f, ok
+}
+
+func (r *Registry) elemType(name string) (reflect.Type, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	t, ok := r.types[name]
+	return t, ok
+}
@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// retries of MapWithRetry
+type RetryPolicy struct {
+	// retries after first attempt
+	Max int
+	// wait before first retry, it is doubled for every next retry
+	Backoff time.Duration
+	// between 0 and 1, every wait is shortened randomly by up to this part of it
+	Jitter float64
+	// errors that are retried, default is all errors
+	RetryIf func(error) bool
+	// default is system clock
+	Clock Clock
+}
+
+func (r RetryPolicy) wait(backoff time.Duration) time.Duration {
+	if r.Jitter <= 0 {
+		return backoff
+	}
+
+	jitter := r.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	return backoff - time.Duration(float64(backoff)*jitter*rand.Float64())
+}
+
+// f is called until it succeeds, it runs out of retries or error should not be retried.
+// elements rejected by an open breaker are not retried
+func (r RetryPolicy) retrying(st *stage, f TryAction) TryAction {
+	clock := clockOrSystem(r.Clock)
+
+	return func(c Content) (Content, error) {
+		backoff := r.Backoff
+		for attempt := 0; ; attempt++ {
+			result, err := f(c)
+			if err == nil || attempt >= r.Max || err == ErrBreakerOpen || (r.RetryIf != nil && !r.RetryIf(err)) {
+				return result, err
+			}
+
+			atomic.AddInt64(&st.retries, 1)
+			clock.Sleep(r.wait(backoff))
+			backoff *= 2
+		}
+	}
+}
+
+func (p *pipeline) retryAction(f TryAction, newType interface{}, policy RetryPolicy) Action {
+	st := p.newStage("MapWithRetry")
+
+	return p.stageAction(st, policy.retrying(st, p.broken(st, p.limited(st, f))), newType)
+}
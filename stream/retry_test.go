@@ -0,0 +1,155 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// clock which only records sleeps
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+}
+
+var _ = Describe("Test MapWithRetry", func() {
+	errBusy := errors.New("busy")
+	errGone := errors.New("gone")
+
+	// element n fails n times before it succeeds
+	flaky := func() TryAction {
+		var mutex sync.Mutex
+		calls := map[int]int{}
+		return func(content Content) (Content, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			n := content.Data.(int)
+			calls[n]++
+			if calls[n] <= n {
+				return Content{}, errBusy
+			}
+			return Content{Data: n * 10}, nil
+		}
+	}
+
+	It("should retry with exponential backoff", func() {
+		clock := &fakeClock{}
+		policy := RetryPolicy{Max: 3, Backoff: 10 * time.Millisecond, Clock: clock}
+		s := Of([]int{0, 3, 1}).MapWithRetry(flaky(), []int{}, policy)
+
+		Expect(s.Interface()).To(Equal([]int{0, 30, 10}))
+		Expect(s.Err()).To(BeNil())
+		Expect(clock.sleeps).To(Equal([]time.Duration{
+			10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 10 * time.Millisecond,
+		}))
+		Expect(s.Trace()).To(Equal([]StageTrace{{Name: "MapWithRetry 1", Retries: 4}}))
+	})
+	It("should drop elements when retries run out", func() {
+		var letters []DeadLetter
+		clock := &fakeClock{}
+		s := Of([]int{1, 5, 2}).
+			DeadLetter(DeadLetterSlice(&letters)).
+			MapWithRetry(flaky(), []int{}, RetryPolicy{Max: 2, Backoff: time.Second, Jitter: 0.5, Clock: clock})
+
+		Expect(s.Interface()).To(Equal([]int{10, 20}))
+		Expect(letters).To(HaveLen(1))
+		Expect(letters[0].Content.Data).To(Equal(5))
+		Expect(letters[0].Err).To(Equal(errBusy))
+		Expect(s.Trace()[0].Retries).To(Equal(5))
+		for _, d := range clock.sleeps {
+			Expect(d).To(BeNumerically(">", 0))
+			Expect(d).To(BeNumerically("<=", 2*time.Second))
+		}
+
+		s = Of([]int{1, 2}).MapWithRetry(flaky(), []int{}, RetryPolicy{Max: 1, Clock: clock})
+		Expect(s.Interface()).To(Equal([]int{10}))
+		Expect(s.Err()).To(Equal(errBusy))
+	})
+	It("should retry only errors matching RetryIf", func() {
+		clock := &fakeClock{}
+		calls := 0
+		s := Of(map[string]int{"a": 1}).MapWithRetry(func(content Content) (Content, error) {
+			calls++
+			return Content{}, errGone
+		}, []int{}, RetryPolicy{Max: 5, Clock: clock, RetryIf: func(err error) bool {
+			return err == errBusy
+		}})
+
+		Expect(s.Interface()).To(Equal([]int{}))
+		Expect(s.Err()).To(Equal(errGone))
+		Expect(calls).To(Equal(1))
+		Expect(clock.sleeps).To(BeEmpty())
+	})
+	It("should retry in parallel and lazy streams", func() {
+		policy := RetryPolicy{Max: 3, Clock: &fakeClock{}}
+		s := Range(0, 4, 1).MapWithRetry(flaky(), []int{}, policy, 2)
+		Expect(s.Count()).To(Equal(4))
+		Expect(s.Trace()[0].Retries).To(Equal(6))
+
+		s = Of([]int{2, 0, 1, 3}).Filter(func(content Content) bool {
+			return content.Data.(int) > 0
+		}).MapWithRetry(flaky(), []int{}, policy, 4)
+		Expect(s.Interface()).To(ConsistOf(20, 10, 30))
+
+		s = Of(map[string]int{"a": 1, "b": 2}).MapWithRetry(func(content Content) (Content, error) {
+			return Content{Key: content.Key, Data: content.Data.(int) * 2}, nil
+		}, map[string]int{}, policy, 4)
+		Expect(s.Interface()).To(Equal(map[string]int{"a": 2, "b": 4}))
+	})
+	It("should not hold other elements behind a slow one", func() {
+		var done int64
+		others := make(chan struct{})
+		waited := false
+		slowFirst := func(content Content) Content {
+			if content.Data.(int) == 0 {
+				select {
+				case <-others:
+				case <-time.After(5 * time.Second):
+					waited = true
+				}
+			} else if atomic.AddInt64(&done, 1) == 19 {
+				close(others)
+			}
+			return content
+		}
+
+		items := make([]int, 20)
+		for i := range items {
+			items[i] = i
+		}
+		// guarded Map stage on four workers, whatever the core count is
+		s := Of(items).RateLimit(RateLimit{N: 100, Interval: time.Second, Burst: 20, Clock: &fakeClock{}}).(*lazy)
+		v := s.mapWith(s.guardAction(slowFirst, []int{}), []int{}, 4).Interface()
+
+		Expect(waited).To(BeFalse())
+		Expect(v).To(ConsistOf(items))
+	})
+	It("should retry on workers", func() {
+		s := Of([]int{3, 0, 2, 1}).(*lazy)
+		v := s.mapWith(s.retryAction(flaky(), []int{}, RetryPolicy{Max: 3, Clock: &fakeClock{}}), []int{}, 4).Interface()
+
+		Expect(v).To(ConsistOf(30, 0, 20, 10))
+		Expect(s.Trace()[0].Retries).To(Equal(6))
+	})
+})
@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// seeded random source, seed is optional
+func newRand(seed ...int64) *rand.Rand {
+	if len(seed) > 0 {
+		return rand.New(rand.NewSource(seed[0]))
+	}
+
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// reservoir sampling (algorithm R), keeps n elements in one pass and returns them in stream order
+func reservoir(it Iterator, n int, r *rand.Rand) ([]Content, error) {
+	if n <= 0 {
+		return nil, it.Close()
+	}
+
+	type slot struct {
+		index   int
+		content Content
+	}
+
+	selected := make([]slot, 0, n)
+	i := 0
+	err := drain(it, func(c Content) bool {
+		if i < n {
+			selected = append(selected, slot{index: i, content: c})
+		} else if j := r.Intn(i + 1); j < n {
+			selected[j] = slot{index: i, content: c}
+		}
+		i++
+		return true
+	})
+
+	sort.Slice(selected, func(x, y int) bool {
+		return selected[x].index < selected[y].index
+	})
+	contents := make([]Content, len(selected))
+	for x, slot := range selected {
+		contents[x] = slot.content
+	}
+
+	return contents, err
+}
+
+// contents at indices
+func (s *lazy) pick(contents []Content, indices []int) IStream {
+	picked := make([]Content, len(indices))
+	for i, index := range indices {
+		picked[i] = contents[index]
+	}
+
+	return s.from(picked)
+}
+
+// map entries are read first and sorted by key so a seed gives the same sample in every run,
+// other streams are sampled as elements are pulled
+func (s *lazy) sampleSource() Iterator {
+	if s.kind != reflect.Map {
+		return s.iterator
+	}
+
+	items := s.items(s.contents())
+	return &entryIterator{items: items, keys: sortedMapKeys(items)}
+}
+
+// keys in a fixed order so a seed gives the same sample in every run
+func sortedMapKeys(items reflect.Value) []reflect.Value {
+	keys := items.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return lessValue(keys[i], keys[j])
+	})
+
+	return keys
+}
+
+func lessValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.String:
+		return a.String() < b.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	default:
+		return fmt.Sprintf("%#v", a.Interface()) < fmt.Sprintf("%#v", b.Interface())
+	}
+}
@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"math/rand"
+	"sort"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Sample", func() {
+	var items []int
+	BeforeEach(func() {
+		items = make([]int, 100)
+		for i := range items {
+			items[i] = i
+		}
+	})
+	Describe("reservoir", func() {
+		It("should pick every index with same probability", func() {
+			r := rand.New(rand.NewSource(7))
+			counts := make([]int, 10)
+			for i := 0; i < 10000; i++ {
+				contents, err := reservoir(Range(0, 10, 1).Iterator(), 3, r)
+				Expect(err).To(BeNil())
+				for _, c := range contents {
+					counts[c.Data.(int)]++
+				}
+			}
+			for _, c := range counts {
+				Expect(c).To(BeNumerically("~", 3000, 200))
+			}
+		})
+		It("should pick all when n is greater than length", func() {
+			contents, _ := reservoir(Range(0, 3, 1).Iterator(), 5, rand.New(rand.NewSource(1)))
+			Expect(contents).To(Equal([]Content{{Data: 0}, {Data: 1}, {Data: 2}}))
+		})
+		It("should keep n elements of a large source", func() {
+			contents, err := reservoir(Range(0, 1000000, 1).Iterator(), 5, rand.New(rand.NewSource(1)))
+			Expect(err).To(BeNil())
+			Expect(contents).To(HaveLen(5))
+		})
+	})
+	Describe("List", func() {
+		It("should sample n elements in order", func() {
+			v := Of(items).Sample(10, 1).Interface().([]int)
+			Expect(len(v)).To(Equal(10))
+			Expect(sort.IntsAreSorted(v)).To(BeTrue())
+		})
+		It("should sample same elements with same seed", func() {
+			Expect(Of(items).Sample(10, 3).Interface()).To(Equal(Of(items).Sample(10, 3).Interface()))
+			Expect(Of(items).SampleFraction(0.2, 3).Interface()).To(Equal(Of(items).SampleFraction(0.2, 3).Interface()))
+			Expect(Of(items).Shuffle(3).Interface()).To(Equal(Of(items).Shuffle(3).Interface()))
+		})
+		It("should sample fraction", func() {
+			v := Of(items).SampleFraction(0.3, 5).Interface().([]int)
+			Expect(len(v)).To(BeNumerically("~", 30, 12))
+			Expect(Of(items).SampleFraction(0).Count()).To(Equal(0))
+			Expect(Of(items).SampleFraction(1).Count()).To(Equal(100))
+		})
+		It("should sample fraction of infinite stream", func() {
+			v := Iterate(0, func(i int) int { return i + 1 }).SampleFraction(0.5, 1).Limit(5).Interface().([]int)
+			Expect(v).To(HaveLen(5))
+			Expect(sort.IntsAreSorted(v)).To(BeTrue())
+		})
+		It("should shuffle", func() {
+			v := Of(items).Shuffle(9).Interface().([]int)
+			Expect(v).NotTo(Equal(items))
+			sort.Ints(v)
+			Expect(v).To(Equal(items))
+		})
+		It("should compose with filter and map", func() {
+			v := Of(items).
+				Filter(func(content Content) bool {
+					return content.Data.(int)%2 == 0
+				}).
+				Sample(5, 2).
+				Map(func(content Content) Content {
+					return Content{Data: content.Data.(int) * 10}
+				}, []int{}).
+				Interface().([]int)
+			Expect(len(v)).To(Equal(5))
+			for _, i := range v {
+				Expect(i % 20).To(Equal(0))
+			}
+		})
+	})
+	Describe("Map", func() {
+		var testMap map[string]testModel
+		BeforeEach(func() {
+			testMap = map[string]testModel{}
+			for _, i := range items {
+				testMap[string(rune('a'+i%26))+string(rune('a'+i/26))] = testModel{Id: i}
+			}
+		})
+		It("should sample n entries", func() {
+			v := Of(testMap).Sample(7, 4).Interface().(map[string]testModel)
+			Expect(len(v)).To(Equal(7))
+			for k, m := range v {
+				Expect(testMap[k]).To(Equal(m))
+			}
+		})
+		It("should sample same entries with same seed", func() {
+			Expect(Of(testMap).Sample(7, 4).Interface()).To(Equal(Of(testMap).Sample(7, 4).Interface()))
+			Expect(Of(testMap).SampleFraction(0.1, 4).Interface()).To(Equal(Of(testMap).SampleFraction(0.1, 4).Interface()))
+		})
+		It("should not shuffle", func() {
+			Expect(Of(testMap).Shuffle().Interface()).To(Equal(testMap))
+		})
+	})
+})
@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"sort"
+	"sync"
+)
+
+// sortEntry keeps the original position of an element next to its content
+// so comparisons do not go through reflect.Value.Index
+type sortEntry struct {
+	index   int
+	content Content
+}
+
+func makeSortEntries(contents []Content) []sortEntry {
+	entries := make([]sortEntry, len(contents))
+	for i, c := range contents {
+		entries[i] = sortEntry{
+			index:   i,
+			content: c,
+		}
+	}
+
+	return entries
+}
+
+// contents in entries order
+func entryContents(entries []sortEntry) []Content {
+	contents := make([]Content, len(entries))
+	for i, e := range entries {
+		contents[i] = e.content
+	}
+
+	return contents
+}
+
+// stable sort, order of equal elements is preserved
+func stableSort(entries []sortEntry, f Compare) {
+	sort.SliceStable(entries, func(x, y int) bool {
+		return f(entries[x].content, entries[y].content) > 0
+	})
+}
+
+// parallel merge sort, every worker sorts its own chunk then chunks are merged pairwise.
+// result is stable since chunks are merged in their original order
+func parallelMergeSort(entries []sortEntry, f Compare, workerCount int) []sortEntry {
+	length := len(entries)
+	if workerCount < 2 || length < 2 {
+		stableSort(entries, f)
+		return entries
+	}
+
+	if workerCount > length {
+		workerCount = length
+	}
+
+	// chunk bounds [worker(chunk), worker1(chunk1), ...]
+	chunkSize := (length + workerCount - 1) / workerCount
+	var bounds []int
+	for st := 0; st < length; st += chunkSize {
+		bounds = append(bounds, st)
+	}
+	bounds = append(bounds, length)
+
+	var wg sync.WaitGroup
+	for i := 0; i < len(bounds)-1; i++ {
+		wg.Add(1)
+		go func(st, end int) {
+			defer wg.Done()
+			stableSort(entries[st:end], f)
+		}(bounds[i], bounds[i+1])
+	}
+	wg.Wait()
+
+	src := entries
+	dst := make([]sortEntry, length)
+	for len(bounds) > 2 {
+		var next []int
+		for i := 0; i < len(bounds)-1; i += 2 {
+			st := bounds[i]
+			next = append(next, st)
+			if i+2 >= len(bounds) {
+				// odd chunk out, copy as is
+				copy(dst[st:], src[st:bounds[i+1]])
+				continue
+			}
+
+			wg.Add(1)
+			go func(st, mid, end int) {
+				defer wg.Done()
+				merge(dst[st:end], src[st:mid], src[mid:end], f)
+			}(st, bounds[i+1], bounds[i+2])
+		}
+		next = append(next, length)
+		wg.Wait()
+
+		bounds = next
+		src, dst = dst, src
+	}
+
+	return src
+}
+
+// merge two sorted chunks, left wins on equality to keep it stable
+func merge(dst, left, right []sortEntry, f Compare) {
+	i, j, k := 0, 0, 0
+	for i < len(left) && j < len(right) {
+		if f(right[j].content, left[i].content) > 0 {
+			dst[k] = right[j]
+			j++
+		} else {
+			dst[k] = left[i]
+			i++
+		}
+		k++
+	}
+
+	k += copy(dst[k:], left[i:])
+	copy(dst[k:], right[j:])
+}
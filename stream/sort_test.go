@@ -0,0 +1,100 @@
+package stream
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Sort", func() {
+	var byId Compare
+	var items []testModel
+	BeforeEach(func() {
+		byId = func(content Content, content2 Content) int {
+			return content2.Data.(testModel).Id - content.Data.(testModel).Id
+		}
+		items = []testModel{
+			{Id: 3, Name: "a"},
+			{Id: 1, Name: "b"},
+			{Id: 2, Name: "c"},
+			{Id: 1, Name: "d"},
+			{Id: 3, Name: "e"},
+			{Id: 2, Name: "f"},
+			{Id: 1, Name: "g"},
+		}
+	})
+	Describe("parallelMergeSort", func() {
+		Context("It should sort", func() {
+			It("with more workers than chunks", func() {
+				entries := parallelMergeSort(makeSortEntries(Of(items).(*lazy).contents()), byId, 4)
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.content.Data.(testModel).Name)
+				}
+				Expect(names).To(Equal([]string{"b", "d", "g", "c", "f", "a", "e"}))
+			})
+			It("with odd chunk count", func() {
+				entries := parallelMergeSort(makeSortEntries(Of(items).(*lazy).contents()), byId, 3)
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.content.Data.(testModel).Name)
+				}
+				Expect(names).To(Equal([]string{"b", "d", "g", "c", "f", "a", "e"}))
+			})
+			It("with more workers than items", func() {
+				entries := parallelMergeSort(makeSortEntries(Of(items[:2]).(*lazy).contents()), byId, 8)
+				Expect(entries[0].content.Data.(testModel).Name).To(Equal("b"))
+				Expect(entries[1].content.Data.(testModel).Name).To(Equal("a"))
+			})
+		})
+	})
+	Describe("List", func() {
+		Context("when sort stable", func() {
+			It("should keep order of equal items", func() {
+				v := Of(items).SortStableBy(byId).Interface().([]testModel)
+				var names []string
+				for _, i := range v {
+					names = append(names, i.Name)
+				}
+				Expect(names).To(Equal([]string{"b", "d", "g", "c", "f", "a", "e"}))
+			})
+			It("should sort after filter", func() {
+				v := Of(items).
+					Filter(func(content Content) bool {
+						return content.Data.(testModel).Id > 1
+					}).
+					SortStableBy(byId).
+					Interface().([]testModel)
+				Expect(v).To(Equal([]testModel{items[2], items[5], items[0], items[4]}))
+			})
+		})
+		Context("when parallel sort", func() {
+			It("should sort with worker count of the stream", func() {
+				l := Of(items).(*lazy)
+				l.workerCount = 4
+				v := l.ParallelSortBy(byId).Interface().([]testModel)
+				var ids []int
+				for _, i := range v {
+					ids = append(ids, i.Id)
+				}
+				Expect(ids).To(Equal([]int{1, 1, 1, 2, 2, 3, 3}))
+			})
+			It("should keep worker count of the stream", func() {
+				l := Of(items).(*lazy)
+				l.workerCount = 4
+				l.ParallelSortBy(byId, 2)
+				Expect(l.workerCount).To(Equal(4))
+			})
+			It("should sort with thread count", func() {
+				v := Of(items).ParallelSortBy(byId, 4).FindFirst()
+				Expect(v).To(Equal(items[1]))
+			})
+		})
+	})
+	Describe("Map", func() {
+		It("should not sort", func() {
+			testMap := map[string]testModel{"a": {Id: 1}, "b": {Id: 2}}
+			v := Of(testMap).SortStableBy(byId).ParallelSortBy(byId, 2).Interface()
+			Expect(v).To(Equal(testMap))
+		})
+	})
+})
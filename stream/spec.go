@@ -0,0 +1,266 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// pipeline defined in YAML, steps run in order and refer to registry names
+//
+//	source:
+//	  type: json
+//	  elem: user
+//	steps:
+//	  - filter: activeUsers
+//	  - filterExpr: Age > 30
+//	  - map: toSummary
+//	  - sortBy: byName
+//	  - skip: 5
+//	  - limit: 10
+type Spec struct {
+	Source SourceSpec `yaml:"source"`
+	Steps  []StepSpec `yaml:"steps"`
+
+	elem   reflect.Type
+	stages []func(IStream) IStream
+}
+
+// how input of Build is read
+type SourceSpec struct {
+	// data for slices and maps, lines, json, ndjson or csv for io.Reader input. default is data
+	Type string `yaml:"type"`
+	// registered element type of json, ndjson and csv sources.
+	// default is map[string]interface{} for json and []string records for csv
+	Elem string `yaml:"elem"`
+	// csv options
+	Header bool   `yaml:"header"`
+	Comma  string `yaml:"comma"`
+}
+
+// one operation and its argument like `filter: activeUsers`.
+// operations: filter, filterExpr, takeWhile, map, sortBy, sortStableBy, skip, limit
+type StepSpec struct {
+	Op  string
+	Arg interface{}
+}
+
+func (s *StepSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var m yaml.MapSlice
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	if len(m) != 1 {
+		return fmt.Errorf("step should have one operation but has %d", len(m))
+	}
+
+	op, ok := m[0].Key.(string)
+	if !ok {
+		return fmt.Errorf("operation %v should be string", m[0].Key)
+	}
+	s.Op, s.Arg = op, m[0].Value
+
+	return nil
+}
+
+// parses and validates spec, every name should be in registry.
+// all problems are reported in one error
+func LoadSpec(data []byte, registry *Registry) (*Spec, error) {
+	spec := &Spec{}
+	if err := yaml.UnmarshalStrict(data, spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+
+	var problems []string
+	if err := spec.compileSource(registry); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for i, step := range spec.Steps {
+		stage, err := compileStep(step, registry)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("step %d %s: %v", i+1, step.Op, err))
+			continue
+		}
+		spec.stages = append(spec.stages, stage)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid spec: %s", strings.Join(problems, "; "))
+	}
+
+	return spec, nil
+}
+
+func (s *Spec) compileSource(registry *Registry) error {
+	switch s.Source.Type {
+	case "", "data", "lines", "json", "ndjson", "csv":
+	default:
+		return fmt.Errorf("source: unknown type %s", s.Source.Type)
+	}
+
+	if utf8.RuneCountInString(s.Source.Comma) > 1 {
+		return fmt.Errorf("source: comma should be one character")
+	}
+
+	if s.Source.Elem == "" {
+		return nil
+	}
+
+	switch s.Source.Type {
+	case "json", "ndjson", "csv":
+	default:
+		return fmt.Errorf("source: elem is only valid for json, ndjson and csv")
+	}
+
+	elem, ok := registry.elemType(s.Source.Elem)
+	if !ok {
+		return fmt.Errorf("source: unknown type %s", s.Source.Elem)
+	}
+	if s.Source.Type == "csv" && (elem.Kind() != reflect.Struct || !s.Source.Header) {
+		return fmt.Errorf("source: csv elem should be struct and needs header")
+	}
+	s.elem = elem
+
+	return nil
+}
+
+func compileStep(step StepSpec, registry *Registry) (func(IStream) IStream, error) {
+	switch step.Op {
+	case "filter", "takeWhile":
+		name, err := stepName(step)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := registry.filter(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %s", name)
+		}
+		if step.Op == "takeWhile" {
+			return func(s IStream) IStream { return s.TakeWhile(f) }, nil
+		}
+		return func(s IStream) IStream { return s.Filter(f) }, nil
+	case "filterExpr":
+		expr, err := stepName(step)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := CompileExpr(expr); err != nil {
+			return nil, err
+		}
+		return func(s IStream) IStream { return s.FilterExpr(expr) }, nil
+	case "map":
+		name, err := stepName(step)
+		if err != nil {
+			return nil, err
+		}
+		a, ok := registry.action(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown action %s", name)
+		}
+		return func(s IStream) IStream { return s.Map(a.f, a.newType) }, nil
+	case "sortBy", "sortStableBy":
+		name, err := stepName(step)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := registry.compare(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown compare %s", name)
+		}
+		if step.Op == "sortStableBy" {
+			return func(s IStream) IStream { return s.SortStableBy(f) }, nil
+		}
+		return func(s IStream) IStream { return s.SortBy(f) }, nil
+	case "skip", "limit":
+		n, ok := step.Arg.(int)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("%v should be a non negative integer", step.Arg)
+		}
+		if step.Op == "skip" {
+			return func(s IStream) IStream { return s.Skip(n) }, nil
+		}
+		return func(s IStream) IStream { return s.Limit(n) }, nil
+	}
+
+	return nil, fmt.Errorf("unknown operation")
+}
+
+func stepName(step StepSpec) (string, error) {
+	name, ok := step.Arg.(string)
+	if !ok || name == "" {
+		return "", fmt.Errorf("%v should be a name", step.Arg)
+	}
+
+	return name, nil
+}
+
+// stream of input with steps applied. input is slice or map for data source, io.Reader for others
+// otherwise stream is empty and Err is set
+func (s *Spec) Build(input interface{}) IStream {
+	stream := s.source(input)
+	for _, stage := range s.stages {
+		stream = stage(stream)
+	}
+
+	return stream
+}
+
+func (s *Spec) source(input interface{}) IStream {
+	if s.Source.Type == "" || s.Source.Type == "data" {
+		switch reflect.ValueOf(input).Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return Of(input)
+		}
+
+		// empty stream of source elements like reader sources
+		elem := reflect.TypeOf((*interface{})(nil)).Elem()
+		if s.elem != nil {
+			elem = s.elem
+		}
+		stream := ofList(newPipeline(), reflect.MakeSlice(reflect.SliceOf(elem), 0, 0))
+		stream.fail(fmt.Errorf("source: data input should be slice, array or map but is %T", input))
+
+		return stream
+	}
+
+	r, ok := input.(io.Reader)
+	if !ok {
+		// empty stream of source elements, error is set before it is read
+		stream := s.readerSource(strings.NewReader("")).(*lazy)
+		stream.fail(fmt.Errorf("source: %s input should be io.Reader but is %T", s.Source.Type, input))
+
+		return stream
+	}
+
+	return s.readerSource(r)
+}
+
+func (s *Spec) readerSource(r io.Reader) IStream {
+	switch s.Source.Type {
+	case "lines":
+		return OfLines(r)
+	case "csv":
+		opts := CSVOptions{Header: s.Source.Header}
+		if s.Source.Comma != "" {
+			opts.Comma, _ = utf8.DecodeRuneInString(s.Source.Comma)
+		}
+		if s.elem != nil {
+			opts.Type = reflect.Zero(s.elem).Interface()
+		}
+		return OfCSV(r, opts)
+	}
+
+	elem := interface{}(map[string]interface{}{})
+	if s.elem != nil {
+		elem = reflect.Zero(s.elem).Interface()
+	}
+	if s.Source.Type == "ndjson" {
+		return OfNDJSON(r, elem)
+	}
+
+	return OfJSONArray(r, elem)
+}
@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type specUser struct {
+	Name   string `json:"name" csv:"name"`
+	Age    int    `json:"age" csv:"age"`
+	Active bool   `json:"active" csv:"active"`
+}
+
+var _ = Describe("Test Spec", func() {
+	registry := NewRegistry().
+		RegisterType("user", specUser{}).
+		RegisterFilter("active", func(content Content) bool {
+			return content.Data.(specUser).Active
+		}).
+		RegisterFilter("adult", func(content Content) bool {
+			return content.Data.(specUser).Age >= 18
+		}).
+		RegisterAction("name", func(content Content) Content {
+			return Content{Data: content.Data.(specUser).Name}
+		}, []string{}).
+		RegisterCompare("byName", func(c1 Content, c2 Content) int {
+			return strings.Compare(c1.Data.(specUser).Name, c2.Data.(specUser).Name)
+		})
+
+	users := []specUser{
+		{Name: "veli", Age: 20, Active: true},
+		{Name: "ali", Age: 30, Active: true},
+		{Name: "ayse", Age: 10, Active: true},
+		{Name: "fatma", Age: 40},
+		{Name: "can", Age: 25, Active: true},
+	}
+
+	It("should build pipeline of data", func() {
+		spec, err := LoadSpec([]byte(`
+steps:
+  - filter: active
+  - filter: adult
+  - sortBy: byName
+  - skip: 1
+  - limit: 1
+  - map: name
+`), registry)
+		Expect(err).To(BeNil())
+		Expect(spec.Build(users).Interface()).To(Equal([]string{"can"}))
+	})
+	It("should be reusable", func() {
+		spec, err := LoadSpec([]byte("steps:\n  - takeWhile: adult\n  - map: name\n"), registry)
+		Expect(err).To(BeNil())
+		Expect(spec.Build(users).Interface()).To(Equal([]string{"veli", "ali"}))
+		Expect(spec.Build(users[1:]).Interface()).To(Equal([]string{"ali"}))
+	})
+	It("should read sources", func() {
+		spec, err := LoadSpec([]byte(`
+source:
+  type: ndjson
+  elem: user
+steps:
+  - filterExpr: Age > 18 && Active
+  - map: name
+`), registry)
+		Expect(err).To(BeNil())
+		data := `{"name":"ali","age":30,"active":true}
+{"name":"veli","age":40}
+{"name":"can","age":20,"active":true}`
+		Expect(spec.Build(strings.NewReader(data)).Interface()).To(Equal([]string{"ali", "can"}))
+
+		spec, err = LoadSpec([]byte("source: {type: csv, elem: user, header: true, comma: ';'}\nsteps: [{filter: active}, {map: name}]"), registry)
+		Expect(err).To(BeNil())
+		data = "name;age;active\nali;30;true\nveli;40;false\n"
+		Expect(spec.Build(strings.NewReader(data)).Interface()).To(Equal([]string{"ali"}))
+
+		spec, err = LoadSpec([]byte("source: {type: lines}\nsteps: [{limit: 2}]"), registry)
+		Expect(err).To(BeNil())
+		Expect(spec.Build(strings.NewReader("a\nb\nc\n")).Interface()).To(Equal([]string{"a", "b"}))
+	})
+	It("should report all problems", func() {
+		_, err := LoadSpec([]byte(`
+source:
+  type: json
+  elem: order
+steps:
+  - filter: missing
+  - map: name
+  - limit: -1
+  - filterExpr: age >
+  - reduce: x
+  - sortBy: 3
+`), registry)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("invalid spec: source: unknown type order; step 1 filter: unknown filter missing; step 3 limit:"))
+		Expect(strings.Count(err.Error(), ";")).To(Equal(5))
+		Expect(err.Error()).To(ContainSubstring("step 5 reduce: unknown operation"))
+	})
+	It("should reject malformed yaml", func() {
+		for _, data := range []string{
+			"steps:\n  - {filter: active, limit: 1}\n",
+			"source: {kind: json}\n",
+			"steps: [",
+		} {
+			_, err := LoadSpec([]byte(data), registry)
+			Expect(err).To(HaveOccurred(), data)
+		}
+	})
+	It("should set error when input is not a reader", func() {
+		spec, err := LoadSpec([]byte("source: {type: json}"), registry)
+		Expect(err).To(BeNil())
+		s := spec.Build(users)
+		Expect(s.Err()).To(MatchError(ContainSubstring("should be io.Reader")))
+		Expect(s.Interface()).To(Equal([]map[string]interface{}{}))
+		Expect(s.Err()).To(MatchError(ContainSubstring("should be io.Reader")))
+	})
+	It("should set error when data input is not a slice or map", func() {
+		spec, err := LoadSpec([]byte("steps: [{limit: 1}]"), registry)
+		Expect(err).To(BeNil())
+		for _, input := range []interface{}{42, nil, strings.NewReader("[]")} {
+			s := spec.Build(input)
+			Expect(s.Err()).To(MatchError(ContainSubstring("should be slice, array or map")))
+			Expect(s.Interface()).To(Equal([]interface{}{}))
+		}
+	})
+})
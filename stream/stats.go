@@ -0,0 +1,232 @@
+package stream
+
+import (
+	"math"
+	"sort"
+)
+
+// Summary of numeric stream
+type Summary struct {
+	Count  int
+	Min    float64
+	Max    float64
+	Mean   float64
+	StdDev float64
+	P50    float64
+	P90    float64
+	P99    float64
+}
+
+// Histogram bucket, holds values in (Lower, Upper]
+type Bucket struct {
+	Lower float64
+	Upper float64
+	Count int
+}
+
+// running count, mean and sum of squared differences (Welford)
+type moments struct {
+	count int
+	mean  float64
+	m2    float64
+	min   float64
+	max   float64
+}
+
+func (m *moments) add(x float64) {
+	if m.count == 0 || x < m.min {
+		m.min = x
+	}
+	if m.count == 0 || x > m.max {
+		m.max = x
+	}
+
+	m.count++
+	delta := x - m.mean
+	m.mean += delta / float64(m.count)
+	m.m2 += delta * (x - m.mean)
+}
+
+// merge moments of another chunk (Chan et al.)
+func (m *moments) merge(o moments) {
+	if o.count == 0 {
+		return
+	}
+	if m.count == 0 {
+		*m = o
+		return
+	}
+
+	count := m.count + o.count
+	delta := o.mean - m.mean
+	m.mean += delta * float64(o.count) / float64(count)
+	m.m2 += o.m2 + delta*delta*float64(m.count)*float64(o.count)/float64(count)
+	m.min = math.Min(m.min, o.min)
+	m.max = math.Max(m.max, o.max)
+	m.count = count
+}
+
+// population variance
+func (m *moments) variance() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+
+	return m.m2 / float64(m.count)
+}
+
+// convert numeric data to float64, it panics for other kinds
+func toFloat(data interface{}) float64 {
+	f, ok := toNumber(data)
+	if !ok {
+		panic("data should be a number or an Extract function should be given")
+	}
+
+	return f
+}
+
+func getExtract(f ...Extract) Extract {
+	if len(f) > 0 && f[0] != nil {
+		return f[0]
+	}
+
+	return func(content Content) float64 {
+		return toFloat(content.Data)
+	}
+}
+
+// one pass over elements, every worker collects its own values and moments then they are merged
+func collectNumbers(length int, content func(i int) Content, f Extract, workerCount int, keepValues bool) ([]float64, moments) {
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	values := make([][]float64, workerCount)
+	partial := make([]moments, workerCount)
+	parallelChunks(length, workerCount, func(worker, st, end int) {
+		var chunk []float64
+		if keepValues {
+			chunk = make([]float64, 0, end-st)
+		}
+
+		m := &partial[worker]
+		for i := st; i < end; i++ {
+			x := f(content(i))
+			m.add(x)
+			if keepValues {
+				chunk = append(chunk, x)
+			}
+		}
+		values[worker] = chunk
+	})
+
+	var all []float64
+	var total moments
+	for i := range partial {
+		total.merge(partial[i])
+		all = append(all, values[i]...)
+	}
+
+	return all, total
+}
+
+// p in [0, 100] of sorted values with linear interpolation between closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 100 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	weight := rank - float64(lower)
+
+	return sorted[lower]*(1-weight) + sorted[upper]*weight
+}
+
+func percentileOf(length int, content func(i int) Content, p float64, workerCount int, f ...Extract) float64 {
+	values, _ := collectNumbers(length, content, getExtract(f...), workerCount, true)
+	sort.Float64s(values)
+
+	return percentile(values, p)
+}
+
+func varianceOf(length int, content func(i int) Content, workerCount int, f ...Extract) float64 {
+	_, m := collectNumbers(length, content, getExtract(f...), workerCount, false)
+
+	return m.variance()
+}
+
+func describe(length int, content func(i int) Content, workerCount int, f ...Extract) Summary {
+	values, m := collectNumbers(length, content, getExtract(f...), workerCount, true)
+	sort.Float64s(values)
+
+	if m.count == 0 {
+		return Summary{
+			Min:    math.NaN(),
+			Max:    math.NaN(),
+			Mean:   math.NaN(),
+			StdDev: math.NaN(),
+			P50:    math.NaN(),
+			P90:    math.NaN(),
+			P99:    math.NaN(),
+		}
+	}
+
+	return Summary{
+		Count:  m.count,
+		Min:    m.min,
+		Max:    m.max,
+		Mean:   m.mean,
+		StdDev: math.Sqrt(m.variance()),
+		P50:    percentile(values, 50),
+		P90:    percentile(values, 90),
+		P99:    percentile(values, 99),
+	}
+}
+
+// bounds should be ascending, buckets are (-Inf, b0], (b0, b1], ... (bn, +Inf)
+func histogram(length int, content func(i int) Content, bounds []float64, workerCount int, f ...Extract) []Bucket {
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	extract := getExtract(f...)
+	counts := make([][]int, workerCount)
+	parallelChunks(length, workerCount, func(worker, st, end int) {
+		chunk := make([]int, len(bounds)+1)
+		for i := st; i < end; i++ {
+			x := extract(content(i))
+			chunk[sort.SearchFloat64s(bounds, x)]++
+		}
+		counts[worker] = chunk
+	})
+
+	buckets := make([]Bucket, len(bounds)+1)
+	for i := range buckets {
+		buckets[i].Lower = math.Inf(-1)
+		if i > 0 {
+			buckets[i].Lower = bounds[i-1]
+		}
+
+		buckets[i].Upper = math.Inf(1)
+		if i < len(bounds) {
+			buckets[i].Upper = bounds[i]
+		}
+
+		for _, chunk := range counts {
+			if chunk != nil {
+				buckets[i].Count += chunk[i]
+			}
+		}
+	}
+
+	return buckets
+}
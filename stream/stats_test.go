@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Stats", func() {
+	var latencies []int
+	var byId Extract
+	BeforeEach(func() {
+		latencies = []int{15, 20, 35, 40, 50, 10, 5, 45, 30, 25}
+		byId = func(content Content) float64 {
+			return float64(content.Data.(testModel).Id)
+		}
+	})
+	Describe("moments", func() {
+		It("should merge chunks", func() {
+			var all, left, right moments
+			for i, l := range latencies {
+				all.add(float64(l))
+				if i < 3 {
+					left.add(float64(l))
+				} else {
+					right.add(float64(l))
+				}
+			}
+			left.merge(right)
+			Expect(left.count).To(Equal(all.count))
+			Expect(left.mean).To(BeNumerically("~", all.mean, 1e-9))
+			Expect(left.variance()).To(BeNumerically("~", all.variance(), 1e-9))
+			Expect(left.min).To(Equal(5.0))
+			Expect(left.max).To(Equal(50.0))
+		})
+		It("should collect numbers in parallel", func() {
+			content := func(i int) Content {
+				return Content{Data: latencies[i]}
+			}
+			values, m := collectNumbers(len(latencies), content, getExtract(), 4, true)
+			Expect(len(values)).To(Equal(10))
+			Expect(m.count).To(Equal(10))
+			Expect(m.mean).To(BeNumerically("~", 27.5, 1e-9))
+			Expect(m.variance()).To(BeNumerically("~", 206.25, 1e-9))
+		})
+	})
+	Describe("List", func() {
+		It("should get percentile", func() {
+			Expect(Of(latencies).Percentile(0)).To(Equal(5.0))
+			Expect(Of(latencies).Percentile(100)).To(Equal(50.0))
+			Expect(Of(latencies).Percentile(90)).To(BeNumerically("~", 45.5, 1e-9))
+		})
+		It("should get median", func() {
+			Expect(Of(latencies).Median()).To(Equal(27.5))
+		})
+		It("should get variance and standard deviation", func() {
+			Expect(Of(latencies).Variance()).To(BeNumerically("~", 206.25, 1e-9))
+			Expect(Of(latencies).StdDev()).To(BeNumerically("~", math.Sqrt(206.25), 1e-9))
+		})
+		It("should get NaN when empty", func() {
+			Expect(math.IsNaN(Of([]float64{}).Median())).To(BeTrue())
+			Expect(math.IsNaN(Of([]float64{}).Variance())).To(BeTrue())
+		})
+		It("should get histogram", func() {
+			buckets := Of(latencies).Histogram([]float64{10, 30})
+			Expect(buckets).To(Equal([]Bucket{
+				{Lower: math.Inf(-1), Upper: 10, Count: 2},
+				{Lower: 10, Upper: 30, Count: 4},
+				{Lower: 30, Upper: math.Inf(1), Count: 4},
+			}))
+		})
+		It("should get histogram in parallel", func() {
+			content := func(i int) Content {
+				return Content{Data: latencies[i]}
+			}
+			buckets := histogram(len(latencies), content, []float64{10, 30}, 4)
+			Expect(buckets).To(Equal(Of(latencies).Histogram([]float64{10, 30})))
+		})
+		It("should describe filtered field", func() {
+			summary := Of([]testModel{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}, {Id: 100}}).
+				Filter(func(content Content) bool {
+					return content.Data.(testModel).Id < 100
+				}).
+				Describe(byId)
+			Expect(summary.Count).To(Equal(4))
+			Expect(summary.Min).To(Equal(1.0))
+			Expect(summary.Max).To(Equal(4.0))
+			Expect(summary.Mean).To(Equal(2.5))
+			Expect(summary.P50).To(Equal(2.5))
+			Expect(summary.P90).To(BeNumerically("~", 3.7, 1e-9))
+			Expect(summary.P99).To(BeNumerically("~", 3.97, 1e-9))
+		})
+		It("should panic when data is not a number", func() {
+			Expect(func() {
+				Of([]string{"a"}).Median()
+			}).To(Panic())
+		})
+	})
+	Describe("Map", func() {
+		It("should describe values", func() {
+			summary := Of(map[string]float64{"a": 1, "b": 2, "c": 3}).Describe()
+			Expect(summary.Count).To(Equal(3))
+			Expect(summary.Mean).To(Equal(2.0))
+			Expect(summary.P50).To(Equal(2.0))
+		})
+		It("should get median of field", func() {
+			Expect(Of(map[string]testModel{"a": {Id: 1}, "b": {Id: 5}}).Median(byId)).To(Equal(3.0))
+		})
+	})
+})
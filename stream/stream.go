@@ -1,23 +1,69 @@
 package stream
 
 import (
-	"math"
+	"context"
+	"io"
 	"reflect"
 )
 
+// streams are single use, terminal functions pull the elements once
 type IStream interface {
 	Filter(f Filter, threadCount ...int) IStream
 	Map(f Action, newType interface{}, threadCount ...int) IStream
+	TryFilter(f TryFilter, threadCount ...int) IStream
+	TryMap(f TryAction, newType interface{}, threadCount ...int) IStream
+	MapWithRetry(f TryAction, newType interface{}, policy RetryPolicy, threadCount ...int) IStream
+	DeadLetter(sink DeadLetterSink) IStream
+	RateLimit(limit RateLimit) IStream
+	Breaker(b *CircuitBreaker) IStream
+	FilterPath(path string, f func(interface{}) bool, threadCount ...int) IStream
+	Pluck(path string) IStream
+	FilterExpr(expr string, threadCount ...int) IStream
+	MapExpr(expr string, newType interface{}, threadCount ...int) IStream
+	MapTo(newType interface{}, threadCount ...int) IStream
+	MapToStrict(newType interface{}, threadCount ...int) IStream
 	Skip(i int) IStream
 	Limit(i int) IStream
+	TakeWhile(f Filter) IStream
 	SortBy(f Compare) IStream
+	SortStableBy(f Compare) IStream
+	ParallelSortBy(f Compare, threadCount ...int) IStream
+	TopK(k int, f Compare, threadCount ...int) IStream
+	BottomK(k int, f Compare, threadCount ...int) IStream
+	Sample(n int, seed ...int64) IStream
+	SampleFraction(p float64, seed ...int64) IStream
+	Shuffle(seed ...int64) IStream
 	FindEdge(f CompareConditional) interface{}
 	Count() int
+	Percentile(p float64, f ...Extract) float64
+	Median(f ...Extract) float64
+	Variance(f ...Extract) float64
+	StdDev(f ...Extract) float64
+	Histogram(buckets []float64, f ...Extract) []Bucket
+	Describe(f ...Extract) Summary
+	ApproxDistinctCount(relativeError float64) int
+	ApproxTopK(k int, epsilon, delta float64) []Frequency
+	ApproxQuantile(q, compression float64, f ...Extract) float64
 	AnyMatch(f Filter) bool
 	AllMatch(f Filter) bool
 	FindFirst() interface{}
 	FindLast() interface{}
 	Interface() interface{}
+	ToChan(bufferSize int, done ...<-chan struct{}) <-chan Content
+	ForEachAsync(f func(Content), threadCount ...int) <-chan struct{}
+	ForEach(f func(Content))
+	ForEachParallel(f func(Content), threadCount int)
+	TryForEach(ctx context.Context, f func(context.Context, Content) error, threadCount ...int) error
+	WriteCSV(w io.Writer, opts CSVOptions) error
+	WriteJSON(w io.Writer) error
+	WriteNDJSON(w io.Writer) error
+	RenderTable(w io.Writer, opts TableOptions) error
+	Iterator() Iterator
+	Err() error
+	Validate(threadCount ...int) IStream
+	OnViolation(sink ViolationSink) IStream
+	Violations() []Violation
+	Trace() []StageTrace
 }
 
 func Of(data interface{}) IStream {
@@ -27,339 +73,10 @@ func Of(data interface{}) IStream {
 	case reflect.Slice:
 		fallthrough
 	case reflect.Array:
-		stream := &list{
-			format: reflect.TypeOf(data),
-			kind:   kind,
-			items:  v,
-		}
-		return stream
+		return ofList(newPipeline(), v)
 	case reflect.Map:
-		stream := &mapping{
-			format: reflect.TypeOf(data),
-			kind:   kind,
-			items:  v,
-		}
-		return stream
+		return ofMapping(newPipeline(), v)
 	default:
 		panic("it should be slice,array or map")
 	}
-	return nil
-}
-
-func parallelListToList(l *list, newType reflect.Type, f Action) IStream {
-	newContent := reflect.MakeSlice(newType, 0, 0)
-	length := l.items.Len()
-	chunkSize := int(math.Ceil(float64(length) / float64(l.workerCount)))
-
-	c := make(chan reflect.Value, l.workerCount)
-
-	worker := func(result chan reflect.Value, st, end int) {
-		newContent := reflect.MakeSlice(newType, 0, 0)
-		for i := st; i < end; i++ {
-			content := Content{
-				Data: l.items.Index(i).Interface(),
-			}
-			c := f(content)
-			v := reflect.ValueOf(c.Data)
-			kind := v.Kind()
-			if kind == reflect.Slice || kind == reflect.Array {
-				newContent = reflect.AppendSlice(newContent, v)
-			} else {
-				newContent = reflect.Append(newContent, v)
-			}
-		}
-		result <- newContent
-	}
-
-	for i := 0; i < l.workerCount; i++ {
-		end := (i + 1) * chunkSize
-		if end > length {
-			end = length
-		}
-		go worker(c, i*chunkSize, end)
-	}
-
-	for i := 0; i < l.workerCount; i++ {
-		newContent = reflect.AppendSlice(newContent, <-c)
-	}
-
-	stream := &list{
-		format: newType,
-		kind:   reflect.Slice,
-		items:  newContent,
-	}
-	return stream
-}
-
-func listToList(l *list, newType reflect.Type, f Action) IStream {
-	if l.workerCount > 1 {
-		return parallelListToList(l, newType, f)
-	}
-
-	newContent := reflect.MakeSlice(newType, 0, 0)
-	for i := 0; i < l.items.Len(); i++ {
-		content := Content{
-			Data: l.items.Index(i).Interface(),
-		}
-		c := f(content)
-		v := reflect.ValueOf(c.Data)
-		kind := v.Kind()
-		if kind == reflect.Slice || kind == reflect.Array {
-			newContent = reflect.AppendSlice(newContent, v)
-		} else {
-			newContent = reflect.Append(newContent, v)
-		}
-	}
-
-	stream := &list{
-		format: newType,
-		kind:   reflect.Slice,
-		items:  newContent,
-	}
-	return stream
-}
-
-func parallelListToMap(l *list, newType reflect.Type, f Action) IStream {
-	newContent := reflect.MakeMap(newType)
-	c := make(chan []keyValue, l.workerCount)
-	length := l.items.Len()
-
-	worker := func(result chan []keyValue, st, end int) {
-		var newContent []keyValue
-		for i := st; i < end; i++ {
-			content := Content{
-				Data: l.items.Index(i).Interface(),
-			}
-			c := f(content)
-			k := reflect.ValueOf(c.Key)
-			v := reflect.ValueOf(c.Data)
-			kind := v.Kind()
-			if kind == reflect.Map {
-				for _, ik := range v.MapKeys() {
-					newContent = append(newContent, keyValue{Key: ik, Value: v.MapIndex(ik)})
-				}
-			} else {
-				newContent = append(newContent, keyValue{Key: k, Value: v})
-			}
-
-		}
-		result <- newContent
-	}
-
-	chunkSize := int(math.Ceil(float64(length) / float64(l.workerCount)))
-	for i := 0; i < l.workerCount; i++ {
-		end := (i + 1) * chunkSize
-		if end > length {
-			end = length
-		}
-		go worker(c, i*chunkSize, end)
-	}
-
-	for i := 0; i < l.workerCount; i++ {
-		keyValues := <-c
-		for _, kv := range keyValues {
-			newContent.SetMapIndex(kv.Key, kv.Value)
-		}
-	}
-
-	stream := &mapping{
-		format: newType,
-		kind:   reflect.Map,
-		items:  newContent,
-	}
-
-	return stream
-}
-
-func listToMap(l *list, newType reflect.Type, f Action) IStream {
-	if l.workerCount > 1 {
-		return parallelListToMap(l, newType, f)
-	}
-
-	newContent := reflect.MakeMap(newType)
-	for i := 0; i < l.items.Len(); i++ {
-		content := Content{
-			Data: l.items.Index(i).Interface(),
-		}
-		c := f(content)
-		k := reflect.ValueOf(c.Key)
-		v := reflect.ValueOf(c.Data)
-		kind := v.Kind()
-		if kind == reflect.Map {
-			for _, ik := range v.MapKeys() {
-				newContent.SetMapIndex(ik, v.MapIndex(ik))
-			}
-		} else {
-			newContent.SetMapIndex(k, v)
-		}
-	}
-
-	stream := &mapping{
-		format: newType,
-		kind:   reflect.Map,
-		items:  newContent,
-	}
-	return stream
-}
-
-func parallelMapToMap(m *mapping, newType reflect.Type, f Action) IStream {
-	newContent := reflect.MakeMap(newType)
-	keys := m.items.MapKeys()
-	c := make(chan []keyValue, m.workerCount)
-	length := len(keys)
-
-	worker := func(result chan []keyValue, st, end int) {
-		var newContent []keyValue
-		for i := st; i < end; i++ {
-			content := Content{
-				Key:  keys[i].Interface(),
-				Data: m.items.MapIndex(keys[i]).Interface(),
-			}
-			c := f(content)
-			k := reflect.ValueOf(c.Key)
-			v := reflect.ValueOf(c.Data)
-
-			kind := v.Kind()
-			if kind == reflect.Map {
-				for _, ik := range v.MapKeys() {
-					newContent = append(newContent, keyValue{Key: ik, Value: v.MapIndex(ik)})
-				}
-			} else {
-				newContent = append(newContent, keyValue{Key: k, Value: v})
-			}
-		}
-		result <- newContent
-	}
-
-	chunkSize := int(math.Ceil(float64(length) / float64(m.workerCount)))
-	for i := 0; i < m.workerCount; i++ {
-		end := (i + 1) * chunkSize
-		if end > length {
-			end = length
-		}
-		go worker(c, i*chunkSize, end)
-	}
-
-	for i := 0; i < m.workerCount; i++ {
-		keyValues := <-c
-		for _, kv := range keyValues {
-			newContent.SetMapIndex(kv.Key, kv.Value)
-		}
-	}
-
-	stream := &mapping{
-		format: newType,
-		kind:   reflect.Map,
-		items:  newContent,
-	}
-
-	return stream
-}
-
-func mapToMap(m *mapping, newType reflect.Type, f Action) IStream {
-	if m.workerCount > 1 {
-		return parallelMapToMap(m, newType, f)
-	}
-
-	newContent := reflect.MakeMap(newType)
-	for _, key := range m.items.MapKeys() {
-		content := Content{
-			Key:  key.Interface(),
-			Data: m.items.MapIndex(key).Interface(),
-		}
-		c := f(content)
-		k := reflect.ValueOf(c.Key)
-		v := reflect.ValueOf(c.Data)
-
-		kind := v.Kind()
-		if kind == reflect.Map {
-			for _, ik := range v.MapKeys() {
-				newContent.SetMapIndex(ik, v.MapIndex(ik))
-			}
-		} else {
-			newContent.SetMapIndex(k, v)
-		}
-	}
-
-	stream := &mapping{
-		format: newType,
-		kind:   reflect.Map,
-		items:  newContent,
-	}
-	return stream
-}
-
-func parallelMapToList(m *mapping, newType reflect.Type, f Action) IStream {
-	newContent := reflect.MakeSlice(newType, 0, 0)
-	keys := m.items.MapKeys()
-	c := make(chan reflect.Value, m.workerCount)
-	length := len(keys)
-
-	worker := func(result chan reflect.Value, st, end int) {
-		newContent := reflect.MakeSlice(newType, 0, 0)
-		for i := st; i < end; i++ {
-			content := Content{
-				Data: m.items.MapIndex(keys[i]).Interface(),
-			}
-			c := f(content)
-			v := reflect.ValueOf(c.Data)
-			kind := v.Kind()
-			if kind == reflect.Slice || kind == reflect.Array {
-				newContent = reflect.AppendSlice(newContent, v)
-			} else {
-				newContent = reflect.Append(newContent, v)
-			}
-		}
-		result <- newContent
-	}
-
-	chunkSize := int(math.Ceil(float64(length) / float64(m.workerCount)))
-	for i := 0; i < m.workerCount; i++ {
-		end := (i + 1) * chunkSize
-		if end > length {
-			end = length
-		}
-		go worker(c, i*chunkSize, end)
-	}
-
-	for i := 0; i < m.workerCount; i++ {
-		newContent = reflect.AppendSlice(newContent, <-c)
-	}
-
-	stream := &list{
-		format: newType,
-		kind:   reflect.Slice,
-		items:  newContent,
-	}
-	return stream
-
-}
-
-func mapToList(m *mapping, newType reflect.Type, f Action) IStream {
-	if m.workerCount > 1 {
-		return parallelMapToList(m, newType, f)
-	}
-
-	newContent := reflect.MakeSlice(newType, 0, 0)
-	for _, key := range m.items.MapKeys() {
-		content := Content{
-			Key:  key.Interface(),
-			Data: m.items.MapIndex(key).Interface(),
-		}
-		c := f(content)
-		v := reflect.ValueOf(c.Data)
-		kind := v.Kind()
-		if kind == reflect.Slice || kind == reflect.Array {
-			newContent = reflect.AppendSlice(newContent, v)
-		} else {
-			newContent = reflect.Append(newContent, v)
-		}
-	}
-
-	stream := &list{
-		format: newType,
-		kind:   reflect.Slice,
-		items:  newContent,
-	}
-	return stream
 }
@@ -0,0 +1,251 @@
+package stream
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type TableStyle int
+
+const (
+	// columns are aligned and separated by two spaces
+	PlainTable TableStyle = iota
+	// cells are in +---+ borders
+	BoxTable
+	// github flavored markdown table, | of cells is escaped
+	MarkdownTable
+)
+
+// RenderTable options, zero value renders all columns as plain table
+type TableOptions struct {
+	// columns in order, they are field names, map keys or paths like "address.city".
+	// default is exported struct fields, keys of map elements or "value" for other elements
+	Columns []string
+	// longer cells are cut and end with "...", 0 is no limit
+	MaxWidth int
+	Style    TableStyle
+	// header of key column of map streams, default is "key"
+	KeyColumn string
+}
+
+type tableColumn struct {
+	name string
+	get  func(data interface{}) interface{}
+}
+
+// all elements are read first to find column widths
+func (p *pipeline) renderTable(w io.Writer, it Iterator, elemType reflect.Type, keyed bool, opts TableOptions) error {
+	var items []Content
+	if err := drain(it, func(c Content) bool {
+		items = append(items, c)
+		return true
+	}); err != nil {
+		p.fail(err)
+		return err
+	}
+
+	columns := tableColumns(elemType, items, opts.Columns)
+	if keyed {
+		keyColumn := opts.KeyColumn
+		if keyColumn == "" {
+			keyColumn = "key"
+		}
+		columns = append([]tableColumn{{name: keyColumn}}, columns...)
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(items)+1)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = opts.cell(column.name)
+	}
+	rows = append(rows, header)
+
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			v := item.Key
+			if column.get != nil {
+				v = column.get(item.Data)
+			}
+			row[i] = opts.cell(CSVOptions{}.format(reflect.ValueOf(v)))
+		}
+		rows = append(rows, row)
+	}
+
+	err := opts.render(w, rows)
+	p.fail(err)
+
+	return err
+}
+
+// given paths, exported fields of struct elements, sorted keys of map elements or the element itself
+func tableColumns(elemType reflect.Type, items []Content, paths []string) []tableColumn {
+	var columns []tableColumn
+	if len(paths) > 0 {
+		for _, path := range paths {
+			path := path
+			columns = append(columns, tableColumn{name: path, get: func(data interface{}) interface{} {
+				v, _ := lookup(data, path)
+				return v
+			}})
+		}
+		return columns
+	}
+
+	t := elemType
+	if t.Kind() == reflect.Interface && len(items) > 0 {
+		t = reflect.TypeOf(items[0].Data)
+		if t == nil {
+			return []tableColumn{{name: "value", get: identity}}
+		}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && t != timeType:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" {
+				name := f.Name
+				columns = append(columns, tableColumn{name: name, get: func(data interface{}) interface{} {
+					v, ok := indirect(reflect.ValueOf(data))
+					if !ok || v.Kind() != reflect.Struct {
+						return nil
+					}
+					// elements of interface streams may have other types
+					if f, ok := v.Type().FieldByName(name); !ok || f.PkgPath != "" {
+						return nil
+					}
+					return valueOf(v.FieldByName(name))
+				}})
+			}
+		}
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		seen := map[string]bool{}
+		var keys []string
+		for _, item := range items {
+			v, ok := indirect(reflect.ValueOf(item.Data))
+			if !ok || v.Kind() != reflect.Map {
+				continue
+			}
+			for _, key := range v.MapKeys() {
+				if !seen[key.String()] {
+					seen[key.String()] = true
+					keys = append(keys, key.String())
+				}
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			key := key
+			columns = append(columns, tableColumn{name: key, get: func(data interface{}) interface{} {
+				v, ok := indirect(reflect.ValueOf(data))
+				if !ok || v.Kind() != reflect.Map {
+					return nil
+				}
+				return valueOf(v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())))
+			}})
+		}
+	default:
+		columns = append(columns, tableColumn{name: "value", get: identity})
+	}
+
+	return columns
+}
+
+func identity(data interface{}) interface{} {
+	return data
+}
+
+// new lines are replaced by space and long cells are cut
+func (opts TableOptions) cell(s string) string {
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ").Replace(s)
+
+	if opts.MaxWidth > 0 && utf8.RuneCountInString(s) > opts.MaxWidth {
+		runes := []rune(s)
+		if opts.MaxWidth <= 3 {
+			s = string(runes[:opts.MaxWidth])
+		} else {
+			s = string(runes[:opts.MaxWidth-3]) + "..."
+		}
+	}
+
+	if opts.Style == MarkdownTable {
+		s = strings.Replace(s, "|", `\|`, -1)
+	}
+
+	return s
+}
+
+// first row is header
+func (opts TableOptions) render(w io.Writer, rows [][]string) error {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	writer := bufio.NewWriter(w)
+	// plain lines have no trailing spaces
+	line := func(row []string, left, sep, right string, plain bool) {
+		var b strings.Builder
+		b.WriteString(left)
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+		}
+		b.WriteString(right)
+
+		text := b.String()
+		if plain {
+			text = strings.TrimRight(text, " ")
+		}
+		writer.WriteString(text)
+		writer.WriteString("\n")
+	}
+	border := func(edge, fill string) {
+		writer.WriteString(edge)
+		for _, width := range widths {
+			writer.WriteString(strings.Repeat(fill, width+2))
+			writer.WriteString(edge)
+		}
+		writer.WriteString("\n")
+	}
+
+	switch opts.Style {
+	case BoxTable:
+		border("+", "-")
+		line(rows[0], "| ", " | ", " |", false)
+		border("+", "-")
+		for _, row := range rows[1:] {
+			line(row, "| ", " | ", " |", false)
+		}
+		border("+", "-")
+	case MarkdownTable:
+		line(rows[0], "| ", " | ", " |", false)
+		border("|", "-")
+		for _, row := range rows[1:] {
+			line(row, "| ", " | ", " |", false)
+		}
+	default:
+		for _, row := range rows {
+			line(row, "", "  ", "", true)
+		}
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type tableAddress struct {
+	City string
+}
+
+type tablePerson struct {
+	Name    string
+	Age     int
+	Score   float64
+	Address *tableAddress
+	secret  string
+}
+
+var _ = Describe("Test RenderTable", func() {
+	people := []tablePerson{
+		{Name: "ali", Age: 30, Score: 1.5, Address: &tableAddress{City: "istanbul"}},
+		{Name: "mehmet", Age: 7, secret: "x"},
+	}
+
+	render := func(s IStream, opts TableOptions) string {
+		var b bytes.Buffer
+		Expect(s.RenderTable(&b, opts)).To(BeNil())
+		return b.String()
+	}
+
+	It("should leave cells of other types empty", func() {
+		type other struct {
+			Age    int
+			secret string
+		}
+		s := Of([]interface{}{people[1], 5, other{Age: 3}, nil})
+		Expect(render(s, TableOptions{})).To(Equal(
+			"Name    Age  Score  Address\n" +
+				"mehmet  7    0\n" +
+				"\n" +
+				"        3\n" +
+				"\n"))
+	})
+	It("should render exported fields", func() {
+		Expect(render(Of(people), TableOptions{})).To(Equal(
+			"Name    Age  Score  Address\n" +
+				"ali     30   1.5    {istanbul}\n" +
+				"mehmet  7    0\n"))
+	})
+	It("should render selected columns", func() {
+		Expect(render(Of(people), TableOptions{Columns: []string{"Address.City", "Name"}})).To(Equal(
+			"Address.City  Name\n" +
+				"istanbul      ali\n" +
+				"              mehmet\n"))
+	})
+	It("should render box and markdown", func() {
+		opts := TableOptions{Columns: []string{"Name", "Age"}, Style: BoxTable}
+		Expect(render(Of(people), opts)).To(Equal(
+			"+--------+-----+\n" +
+				"| Name   | Age |\n" +
+				"+--------+-----+\n" +
+				"| ali    | 30  |\n" +
+				"| mehmet | 7   |\n" +
+				"+--------+-----+\n"))
+
+		opts.Style = MarkdownTable
+		Expect(render(Of([]string{"a|b", "c"}), opts)).To(Equal(
+			"| Name | Age |\n" +
+				"|------|-----|\n" +
+				"|      |     |\n" +
+				"|      |     |\n"))
+		Expect(render(Of([]string{"a|b", "c"}), TableOptions{Style: MarkdownTable})).To(Equal(
+			"| value |\n" +
+				"|-------|\n" +
+				"| a\\|b  |\n" +
+				"| c     |\n"))
+	})
+	It("should cut long cells", func() {
+		data := []string{"short", "a very long value", "line\nbreak"}
+		Expect(render(Of(data), TableOptions{MaxWidth: 8})).To(Equal("value\nshort\na ver...\nline ...\n"))
+		Expect(render(Of([]string{"abcdef"}), TableOptions{MaxWidth: 2})).To(Equal("va\nab\n"))
+	})
+	It("should render maps with key column", func() {
+		data := map[string]map[string]interface{}{
+			"b": {"x": 1, "y": "yes"},
+			"a": {"x": 2.5},
+		}
+		out := render(Of(data), TableOptions{KeyColumn: "id"})
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		Expect(lines[0]).To(Equal("id  x    y"))
+		Expect(lines[1:]).To(ConsistOf("a   2.5", "b   1    yes"))
+	})
+	It("should render lazy streams", func() {
+		s := Range(1, 4, 1).Map(func(content Content) Content {
+			return Content{Data: map[string]interface{}{"n": content.Data, "sq": content.Data.(int) * content.Data.(int)}}
+		}, []interface{}{})
+		Expect(render(s, TableOptions{Style: BoxTable})).To(Equal(
+			"+---+----+\n" +
+				"| n | sq |\n" +
+				"+---+----+\n" +
+				"| 1 | 1  |\n" +
+				"| 2 | 4  |\n" +
+				"| 3 | 9  |\n" +
+				"+---+----+\n"))
+		Expect(render(Of([]tablePerson{}), TableOptions{Columns: []string{"Name"}})).To(Equal("Name\n"))
+	})
+	It("should return errors", func() {
+		s := OfLines(&failingReader{data: "a\n"})
+		var b bytes.Buffer
+		Expect(s.RenderTable(&b, TableOptions{})).To(HaveOccurred())
+		Expect(s.Err()).To(HaveOccurred())
+
+		Expect(Of(people).RenderTable(failingWriter{}, TableOptions{})).To(MatchError(errors.New("write failed")))
+	})
+})
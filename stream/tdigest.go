@@ -0,0 +1,139 @@
+package stream
+
+import (
+	"math"
+	"sort"
+)
+
+type centroid struct {
+	mean  float64
+	count float64
+}
+
+// merging t-digest, quantile error gets smaller near the tails.
+// higher compression keeps more centroids and gives better accuracy
+type tDigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min         float64
+	max         float64
+}
+
+func newTDigest(compression float64) *tDigest {
+	if compression <= 0 {
+		compression = 100
+	}
+
+	return &tDigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+func (t *tDigest) add(x float64) {
+	t.buffer = append(t.buffer, centroid{mean: x, count: 1})
+	t.count++
+	t.min = math.Min(t.min, x)
+	t.max = math.Max(t.max, x)
+
+	if len(t.buffer) >= int(t.compression)*5 {
+		t.compress()
+	}
+}
+
+func (t *tDigest) merge(o *tDigest) {
+	if o.count == 0 {
+		return
+	}
+
+	t.buffer = append(t.buffer, o.centroids...)
+	t.buffer = append(t.buffer, o.buffer...)
+	t.count += o.count
+	t.min = math.Min(t.min, o.min)
+	t.max = math.Max(t.max, o.max)
+	t.compress()
+}
+
+// k1 scale function
+func (t *tDigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// merge neighbour centroids while they stay in one unit of scale
+func (t *tDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+
+	all := append(t.centroids, t.buffer...)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].mean < all[j].mean
+	})
+
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	current := all[0]
+	left := 0.0
+	for _, c := range all[1:] {
+		proposed := current.count + c.count
+		if t.scale((left+proposed)/t.count)-t.scale(left/t.count) <= 1 {
+			current.mean += (c.mean - current.mean) * c.count / proposed
+			current.count = proposed
+			continue
+		}
+
+		left += current.count
+		merged = append(merged, current)
+		current = c
+	}
+	merged = append(merged, current)
+
+	t.centroids = merged
+	t.buffer = nil
+}
+
+// q in [0, 1], interpolates between centroid centers
+func (t *tDigest) quantile(q float64) float64 {
+	t.compress()
+
+	if len(t.centroids) == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+
+	c := t.centroids
+	if len(c) == 1 {
+		return c[0].mean
+	}
+
+	target := q * t.count
+
+	// between min and first center
+	if half := c[0].count / 2; target < half {
+		return t.min + (c[0].mean-t.min)*target/half
+	}
+
+	cumulative := 0.0
+	for i := 0; i < len(c)-1; i++ {
+		left := cumulative + c[i].count/2
+		right := cumulative + c[i].count + c[i+1].count/2
+		if target <= right {
+			return c[i].mean + (c[i+1].mean-c[i].mean)*(target-left)/(right-left)
+		}
+		cumulative += c[i].count
+	}
+
+	// between last center and max
+	last := c[len(c)-1]
+	half := last.count / 2
+	center := t.count - half
+
+	return last.mean + (t.max-last.mean)*(target-center)/half
+}
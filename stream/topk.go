@@ -0,0 +1,127 @@
+package stream
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// boundedHeap keeps the first k entries in Compare order.
+// root is the entry that comes last so it is the one evicted
+type boundedHeap struct {
+	entries []sortEntry
+	k       int
+	f       Compare
+}
+
+func (h *boundedHeap) Len() int { return len(h.entries) }
+
+func (h *boundedHeap) Less(i, j int) bool {
+	c := h.f(h.entries[j].content, h.entries[i].content)
+	if c == 0 {
+		// later element is evicted first on equality
+		return h.entries[i].index > h.entries[j].index
+	}
+
+	return c > 0
+}
+
+func (h *boundedHeap) Swap(i, j int) { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+
+func (h *boundedHeap) Push(x interface{}) { h.entries = append(h.entries, x.(sortEntry)) }
+
+func (h *boundedHeap) Pop() interface{} {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+
+	return last
+}
+
+func (h *boundedHeap) offer(e sortEntry) {
+	if h.k <= 0 {
+		return
+	}
+
+	if len(h.entries) < h.k {
+		heap.Push(h, e)
+		return
+	}
+
+	// replace root if new entry comes before it
+	root := h.entries[0]
+	c := h.f(e.content, root.content)
+	if c > 0 || (c == 0 && e.index < root.index) {
+		h.entries[0] = e
+		heap.Fix(h, 0)
+	}
+}
+
+// entries in Compare order
+func (h *boundedHeap) sorted() []sortEntry {
+	sorted := make([]sortEntry, len(h.entries))
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(h).(sortEntry)
+	}
+
+	return sorted
+}
+
+// find first k entries of items in f order. every worker fills its own heap then heaps are merged
+func topK(length int, content func(i int) Content, k int, f Compare, workerCount int) []sortEntry {
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	fill := func(st, end int) *boundedHeap {
+		h := &boundedHeap{k: k, f: f}
+		for i := st; i < end; i++ {
+			h.offer(sortEntry{index: i, content: content(i)})
+		}
+
+		return h
+	}
+
+	heaps := make([]*boundedHeap, workerCount)
+	parallelChunks(length, workerCount, func(worker, st, end int) {
+		heaps[worker] = fill(st, end)
+	})
+
+	merged := &boundedHeap{k: k, f: f}
+	for _, h := range heaps {
+		if h == nil {
+			continue
+		}
+		for _, e := range h.entries {
+			merged.offer(e)
+		}
+	}
+
+	return merged.sorted()
+}
+
+func reverse(f Compare) Compare {
+	return func(c1 Content, c2 Content) int {
+		return f(c2, c1)
+	}
+}
+
+func (s *lazy) topK(k int, f Compare, bottom bool, threadCount ...int) IStream {
+	if len(threadCount) > 0 {
+		s.workerCount = getThreadCount(threadCount...)
+	}
+
+	length, content := s.elements()
+
+	var entries []sortEntry
+	if bottom {
+		entries = topK(length, content, k, reverse(f), s.workerCount)
+		// back to f order
+		sort.Slice(entries, func(x, y int) bool {
+			return entries[x].index < entries[y].index
+		})
+		stableSort(entries, f)
+	} else {
+		entries = topK(length, content, k, f, s.workerCount)
+	}
+
+	return s.from(entryContents(entries))
+}
@@ -0,0 +1,98 @@
+package stream
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test TopK", func() {
+	var byId Compare
+	var items []testModel
+	BeforeEach(func() {
+		// ascending by id
+		byId = func(content Content, content2 Content) int {
+			return content2.Data.(testModel).Id - content.Data.(testModel).Id
+		}
+		items = []testModel{
+			{Id: 5, Name: "a"},
+			{Id: 1, Name: "b"},
+			{Id: 9, Name: "c"},
+			{Id: 2, Name: "d"},
+			{Id: 7, Name: "e"},
+			{Id: 2, Name: "f"},
+			{Id: 8, Name: "g"},
+			{Id: 3, Name: "h"},
+		}
+	})
+	Describe("topK", func() {
+		Context("It should merge worker heaps", func() {
+			It("when parallel", func() {
+				content := func(i int) Content {
+					return Content{Data: items[i]}
+				}
+				for _, workerCount := range []int{1, 2, 3, 4} {
+					entries := topK(len(items), content, 3, byId, workerCount)
+					var names []string
+					for _, e := range entries {
+						names = append(names, e.content.Data.(testModel).Name)
+					}
+					Expect(names).To(Equal([]string{"b", "d", "f"}))
+				}
+			})
+		})
+	})
+	Describe("List", func() {
+		Context("when top k", func() {
+			It("should get first k elements in order", func() {
+				v := Of(items).TopK(3, byId).Interface().([]testModel)
+				Expect(v).To(Equal([]testModel{items[1], items[3], items[5]}))
+			})
+			It("should get all elements when k is greater than count", func() {
+				v := Of(items).TopK(20, byId).Interface().([]testModel)
+				Expect(len(v)).To(Equal(len(items)))
+				Expect(v[0]).To(Equal(items[1]))
+				Expect(v[len(v)-1]).To(Equal(items[2]))
+			})
+			It("should be empty when k is zero", func() {
+				Expect(Of(items).TopK(0, byId).Count()).To(Equal(0))
+			})
+			It("should apply filter before", func() {
+				v := Of(items).
+					Filter(func(content Content) bool {
+						return content.Data.(testModel).Id > 2
+					}).
+					TopK(2, byId).
+					Interface().([]testModel)
+				Expect(v).To(Equal([]testModel{items[7], items[0]}))
+			})
+			It("should be same as sort and limit", func() {
+				top := Of(items).TopK(4, byId, 4).Interface()
+				sorted := Of(items).SortStableBy(byId).Limit(4).Interface()
+				Expect(top).To(Equal(sorted))
+			})
+		})
+		Context("when bottom k", func() {
+			It("should get last k elements in order", func() {
+				v := Of(items).BottomK(3, byId).Interface().([]testModel)
+				Expect(v).To(Equal([]testModel{items[4], items[6], items[2]}))
+			})
+		})
+	})
+	Describe("Map", func() {
+		var testMap map[string]testModel
+		BeforeEach(func() {
+			testMap = map[string]testModel{}
+			for _, i := range items {
+				testMap[i.Name] = i
+			}
+		})
+		It("should get top k entries", func() {
+			v := Of(testMap).TopK(4, byId).Interface().(map[string]testModel)
+			Expect(v).To(Equal(map[string]testModel{"b": items[1], "d": items[3], "f": items[5], "h": items[7]}))
+		})
+		It("should get bottom k entries", func() {
+			v := Of(testMap).BottomK(2, byId).Interface().(map[string]testModel)
+			Expect(v).To(Equal(map[string]testModel{"c": items[2], "g": items[6]}))
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package stream
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// counters of a traced stage. stages are traced when they are added after DeadLetter
+// or they can fail like TryFilter, TryMap and MapWithRetry
+type StageTrace struct {
+	// operation and its number in trace like "Map 2"
+	Name string
+	// elements sent to dead letter sink
+	DeadLettered int
+	// extra attempts of MapWithRetry
+	Retries int
+	// time spent waiting for rate limit tokens, sum of all workers
+	Wait time.Duration
+	// elements rejected or given to Fallback by open breaker
+	Rejected int
+}
+
+type stage struct {
+	name         string
+	deadLettered int64
+	retries      int64
+	wait         int64
+	rejected     int64
+}
+
+func (p *pipeline) newStage(operation string) *stage {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	st := &stage{name: fmt.Sprintf("%s %d", operation, len(p.stages)+1)}
+	p.stages = append(p.stages, st)
+
+	return st
+}
+
+// counters of traced stages in order they are added, read them after the terminal function
+func (p *pipeline) Trace() []StageTrace {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	trace := make([]StageTrace, len(p.stages))
+	for i, st := range p.stages {
+		trace[i] = StageTrace{
+			Name:         st.name,
+			DeadLettered: int(atomic.LoadInt64(&st.deadLettered)),
+			Retries:      int(atomic.LoadInt64(&st.retries)),
+			Wait:         time.Duration(atomic.LoadInt64(&st.wait)),
+			Rejected:     int(atomic.LoadInt64(&st.rejected)),
+		}
+	}
+
+	return trace
+}
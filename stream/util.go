@@ -1,6 +1,9 @@
 package stream
 
-import "runtime"
+import (
+	"runtime"
+	"sync"
+)
 
 func getThreadCount(desiredThreadCount ...int) int {
 	if len(desiredThreadCount) == 0 {
@@ -17,3 +20,32 @@ func getThreadCount(desiredThreadCount ...int) int {
 
 	return 1
 }
+
+// split [0, length) into chunks [worker(chunk), worker1(chunk1), ...] and run fn for every chunk.
+// it returns when all chunks are done, single worker runs on caller goroutine
+func parallelChunks(length, workerCount int, fn func(worker, st, end int)) {
+	if workerCount <= 1 || length < workerCount {
+		fn(0, 0, length)
+		return
+	}
+
+	chunkSize := (length + workerCount - 1) / workerCount
+
+	var wg sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		st, end := i*chunkSize, (i+1)*chunkSize
+		if st > length {
+			st = length
+		}
+		if end > length {
+			end = length
+		}
+
+		wg.Add(1)
+		go func(i, st, end int) {
+			defer wg.Done()
+			fn(i, st, end)
+		}(i, st, end)
+	}
+	wg.Wait()
+}
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"runtime"
+	"sync/atomic"
 )
 
 var _ = Describe("Test Util", func() {
@@ -25,4 +26,19 @@ var _ = Describe("Test Util", func() {
 			})
 		})
 	})
+	Describe("parallelChunks", func() {
+		It("should cover every index once", func() {
+			for _, workerCount := range []int{1, 3, 4, 16} {
+				seen := make([]int32, 10)
+				parallelChunks(len(seen), workerCount, func(worker, st, end int) {
+					for i := st; i < end; i++ {
+						atomic.AddInt32(&seen[i], 1)
+					}
+				})
+				for i := range seen {
+					Expect(seen[i]).To(Equal(int32(1)), "worker count %d", workerCount)
+				}
+			}
+		})
+	})
 })
@@ -0,0 +1,396 @@
+package stream
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// failed rule of an element field
+type Violation struct {
+	// index of list element or key of map element
+	Key interface{}
+	// field path like "Address.City" or "Items[0].Name"
+	Field string
+	// rule of validate tag like "min=1"
+	Rule  string
+	Value interface{}
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%v: %s failed %s", v.Key, v.Field, v.Rule)
+}
+
+// receives violations of Validate stages in element order, stage waits while it runs
+type ViolationSink func(Violation)
+
+// violations are sent to channel, stage waits while channel is full
+func ViolationChan(ch chan<- Violation) ViolationSink {
+	return func(violation Violation) {
+		ch <- violation
+	}
+}
+
+// rules are compiled once per struct type
+var validationPlans sync.Map
+
+type validationPlan struct {
+	rules *structRules
+	err   error
+}
+
+type structRules struct {
+	fields []fieldRules
+}
+
+type fieldRules struct {
+	index int
+	name  string
+	rules []rule
+	// rules of struct fields, elements of slices and values of pointers
+	nested *structRules
+}
+
+type rule struct {
+	text  string
+	check func(v reflect.Value) bool
+}
+
+func (p *pipeline) violate(violations []Violation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.violations = append(p.violations, violations...)
+}
+
+func (p *pipeline) setViolationSink(sink ViolationSink) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.violationSink = sink
+}
+
+// violations go to sink of the stream, otherwise they are kept for Violations
+func (p *pipeline) reporter() func([]Violation) {
+	p.mutex.Lock()
+	sink := p.violationSink
+	p.mutex.Unlock()
+
+	if sink == nil {
+		return p.violate
+	}
+
+	return func(violations []Violation) {
+		for _, violation := range violations {
+			sink(violation)
+		}
+	}
+}
+
+// violations of Validate stages without sink, elements of a stage are in stream order
+func (p *pipeline) Violations() []Violation {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]Violation{}, p.violations...)
+}
+
+// violations of data, rule errors are set to pipeline and data is not valid
+func (p *pipeline) validate(data interface{}, key interface{}) ([]Violation, bool) {
+	v, ok := indirect(reflect.ValueOf(data))
+	if !ok || v.Kind() != reflect.Struct {
+		return nil, true
+	}
+
+	rules, err := rulesOf(v.Type())
+	if err != nil {
+		p.fail(err)
+		return nil, false
+	}
+
+	violations := rules.check(v, "", key, nil)
+
+	return violations, len(violations) == 0
+}
+
+// elements are numbered as they are pulled, workers check them like parallel Filter
+// rule errors of element type are set before elements are pulled
+func (p *pipeline) validateIterator(it Iterator, elemType reflect.Type, keyed bool, workerCount int) Iterator {
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() == reflect.Struct {
+		_, err := rulesOf(elemType)
+		p.fail(err)
+	}
+
+	keyOf := func(c Content, i int) interface{} {
+		if keyed {
+			return c.Key
+		}
+		return i
+	}
+
+	report := p.reporter()
+	if workerCount <= 1 {
+		i := 0
+		return &filterIterator{source: it, f: func(c Content) bool {
+			violations, ok := p.validate(c.Data, keyOf(c, i))
+			report(violations)
+			i++
+			return ok
+		}}
+	}
+
+	order := &violationOrder{pending: map[int][]Violation{}, report: report}
+	return newParallelIterator(&numberIterator{source: it}, workerCount, func(c Content) []Content {
+		n := c.Key.(numbered)
+		c.Key = n.key
+
+		violations, ok := p.validate(c.Data, keyOf(c, n.index))
+		order.done(n.index, violations)
+		if ok {
+			return []Content{c}
+		}
+		return nil
+	})
+}
+
+// violations of parallel workers are held until violations of previous elements are reported
+type violationOrder struct {
+	mutex   sync.Mutex
+	next    int
+	pending map[int][]Violation
+	report  func([]Violation)
+}
+
+func (o *violationOrder) done(index int, violations []Violation) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.pending[index] = violations
+	for {
+		violations, ok := o.pending[o.next]
+		if !ok {
+			return
+		}
+		delete(o.pending, o.next)
+		o.next++
+		o.report(violations)
+	}
+}
+
+type numbered struct {
+	index int
+	key   interface{}
+}
+
+// Key of elements is numbered with original key
+type numberIterator struct {
+	source Iterator
+	count  int
+}
+
+func (it *numberIterator) Next() (Content, bool) {
+	c, ok := it.source.Next()
+	if !ok {
+		return c, false
+	}
+	c.Key = numbered{index: it.count, key: c.Key}
+	it.count++
+
+	return c, true
+}
+
+func (it *numberIterator) Close() error {
+	return it.source.Close()
+}
+
+func rulesOf(t reflect.Type) (*structRules, error) {
+	if plan, ok := validationPlans.Load(t); ok {
+		return plan.(validationPlan).rules, plan.(validationPlan).err
+	}
+
+	rules, err := compileRules(t, map[reflect.Type]*structRules{})
+	if err != nil {
+		rules = nil
+	}
+	validationPlans.Store(t, validationPlan{rules: rules, err: err})
+
+	return rules, err
+}
+
+// nil when type has no rules
+func compileRules(t reflect.Type, compiling map[reflect.Type]*structRules) (*structRules, error) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	if rules, ok := compiling[t]; ok {
+		return rules, nil
+	}
+
+	rules := &structRules{}
+	compiling[t] = rules
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		field := fieldRules{index: i, name: f.Name}
+		if tag := f.Tag.Get("validate"); tag != "" && tag != "-" {
+			for _, text := range strings.Split(tag, ",") {
+				r, err := compileRule(strings.TrimSpace(text), f.Type)
+				if err != nil {
+					return nil, fmt.Errorf("validate tag of %s.%s: %v", t, f.Name, err)
+				}
+				field.rules = append(field.rules, r)
+			}
+		}
+
+		nested, err := compileRules(f.Type, compiling)
+		if err != nil {
+			return nil, err
+		}
+		field.nested = nested
+
+		if len(field.rules) > 0 || nested != nil {
+			rules.fields = append(rules.fields, field)
+		}
+	}
+
+	if len(rules.fields) == 0 {
+		delete(compiling, t)
+		return nil, nil
+	}
+
+	return rules, nil
+}
+
+func (r *structRules) check(v reflect.Value, prefix string, key interface{}, out []Violation) []Violation {
+	for _, f := range r.fields {
+		fv := v.Field(f.index)
+		path := prefix + f.name
+
+		for _, rl := range f.rules {
+			if !rl.check(fv) {
+				out = append(out, Violation{Key: key, Field: path, Rule: rl.text, Value: valueOf(fv)})
+			}
+		}
+
+		if f.nested != nil {
+			out = f.nested.checkValue(fv, path, key, out)
+		}
+	}
+
+	return out
+}
+
+// structs behind pointers and in slices
+func (r *structRules) checkValue(v reflect.Value, path string, key interface{}, out []Violation) []Violation {
+	v, ok := indirect(v)
+	if !ok {
+		return out
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return r.check(v, path+".", key, out)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out = r.checkValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), key, out)
+		}
+	}
+
+	return out
+}
+
+// required, min=n, max=n, len=n and oneof=a b c. nil pointers only fail required
+func compileRule(text string, t reflect.Type) (rule, error) {
+	name, param := text, ""
+	if i := strings.Index(text, "="); i >= 0 {
+		name, param = text[:i], text[i+1:]
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var check func(v reflect.Value) bool
+	switch name {
+	case "required":
+		return rule{text: text, check: func(v reflect.Value) bool {
+			return !v.IsZero()
+		}}, nil
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return rule{}, fmt.Errorf("%s needs a number", name)
+		}
+		size, err := sizeOf(t)
+		if err != nil {
+			return rule{}, fmt.Errorf("%s: %v", name, err)
+		}
+		check = func(v reflect.Value) bool {
+			switch name {
+			case "min":
+				return size(v) >= n
+			case "max":
+				return size(v) <= n
+			}
+			return size(v) == n
+		}
+	case "oneof":
+		if t.Kind() != reflect.String && !isNumberKind(t.Kind()) {
+			return rule{}, fmt.Errorf("oneof is not valid for %s", t)
+		}
+		values := strings.Fields(param)
+		check = func(v reflect.Value) bool {
+			s := fmt.Sprint(v.Interface())
+			for _, value := range values {
+				if s == value {
+					return true
+				}
+			}
+			return false
+		}
+	default:
+		return rule{}, fmt.Errorf("unknown rule %s", name)
+	}
+
+	return rule{text: text, check: func(v reflect.Value) bool {
+		v, ok := indirect(v)
+		return !ok || check(v)
+	}}, nil
+}
+
+// numbers are compared by value, strings by rune count and others by length
+func sizeOf(t reflect.Type) (func(v reflect.Value) float64, error) {
+	switch {
+	case isNumberKind(t.Kind()):
+		return func(v reflect.Value) float64 {
+			f, _ := toNumber(v.Interface())
+			return f
+		}, nil
+	case t.Kind() == reflect.String:
+		return func(v reflect.Value) float64 {
+			return float64(utf8.RuneCountInString(v.String()))
+		}, nil
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map:
+		return func(v reflect.Value) float64 {
+			return float64(v.Len())
+		}, nil
+	}
+
+	return nil, fmt.Errorf("not valid for %s", t)
+}
@@ -0,0 +1,148 @@
+package stream
+
+import (
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type validItem struct {
+	Sku string `validate:"required,len=3"`
+	Qty int    `validate:"min=1,max=10"`
+}
+
+type validOrder struct {
+	Id     int      `validate:"required"`
+	Status string   `validate:"oneof=new paid shipped"`
+	Note   *string  `validate:"max=5"`
+	Tags   []string `validate:"max=2"`
+	Items  []validItem
+	Parent *validOrder
+}
+
+var _ = Describe("Test Validate", func() {
+	long := "too long note"
+	orders := []validOrder{
+		{Id: 1, Status: "new", Items: []validItem{{Sku: "abc", Qty: 1}}},
+		{Id: 0, Status: "lost", Note: &long},
+		{Id: 3, Status: "paid", Items: []validItem{{Sku: "abc", Qty: 2}, {Sku: "x", Qty: 11}}},
+		{Id: 4, Status: "shipped", Tags: []string{"a", "b", "c"}, Parent: &validOrder{Status: "new"}},
+		{Id: 5, Status: "paid"},
+	}
+
+	It("should keep valid elements and report violations", func() {
+		s := Of(orders).Validate()
+		Expect(s.Interface()).To(Equal([]validOrder{orders[0], orders[4]}))
+		Expect(s.Violations()).To(Equal([]Violation{
+			{Key: 1, Field: "Id", Rule: "required", Value: 0},
+			{Key: 1, Field: "Status", Rule: "oneof=new paid shipped", Value: "lost"},
+			{Key: 1, Field: "Note", Rule: "max=5", Value: &long},
+			{Key: 2, Field: "Items[1].Sku", Rule: "len=3", Value: "x"},
+			{Key: 2, Field: "Items[1].Qty", Rule: "max=10", Value: 11},
+			{Key: 3, Field: "Tags", Rule: "max=2", Value: []string{"a", "b", "c"}},
+			{Key: 3, Field: "Parent.Id", Rule: "required", Value: 0},
+		}))
+		Expect(s.Violations()[0].Error()).To(Equal("1: Id failed required"))
+		Expect(s.Err()).To(BeNil())
+	})
+	It("should validate in parallel", func() {
+		var many []validItem
+		for i := 0; i < 100; i++ {
+			many = append(many, validItem{Sku: "abc", Qty: i % 12})
+		}
+		s := Of(many).Validate(4)
+		Expect(len(s.Interface().([]validItem))).To(Equal(83))
+
+		violations := s.Violations()
+		Expect(violations).To(HaveLen(17))
+		for i := 1; i < len(violations); i++ {
+			Expect(violations[i-1].Key.(int)).To(BeNumerically("<", violations[i].Key.(int)))
+		}
+	})
+	It("should report violations of workers in element order", func() {
+		var many []validItem
+		for i := 0; i < 200; i++ {
+			many = append(many, validItem{Sku: "abc", Qty: i % 12})
+		}
+		p := newPipeline()
+		it := p.validateIterator(Of(many).Iterator(), reflect.TypeOf(validItem{}), false, 4)
+		count := 0
+		Expect(drain(it, func(Content) bool {
+			count++
+			return true
+		})).To(BeNil())
+
+		Expect(count).To(Equal(167))
+		violations := p.Violations()
+		Expect(violations).To(HaveLen(33))
+		for i := 1; i < len(violations); i++ {
+			Expect(violations[i-1].Key.(int)).To(BeNumerically("<", violations[i].Key.(int)))
+		}
+	})
+	It("should send violations to sink", func() {
+		var keys []interface{}
+		s := Of([]validItem{{Sku: "abc", Qty: 0}, {Sku: "abc", Qty: 1}, {Sku: "abc", Qty: 20}}).
+			OnViolation(func(violation Violation) {
+				keys = append(keys, violation.Key)
+			}).
+			Validate()
+		Expect(s.Interface()).To(Equal([]validItem{{Sku: "abc", Qty: 1}}))
+		Expect(keys).To(Equal([]interface{}{0, 2}))
+		Expect(s.Violations()).To(BeEmpty())
+
+		ch := make(chan Violation, 1)
+		done := Range(0, 3, 1).Map(func(content Content) Content {
+			return Content{Data: validItem{Sku: "abc", Qty: content.Data.(int) * 10}}
+		}, []validItem{}).OnViolation(ViolationChan(ch)).Validate().ToChan(0)
+		Expect((<-ch).Key).To(Equal(0))
+		Expect((<-done).Data).To(Equal(validItem{Sku: "abc", Qty: 10}))
+		Expect((<-ch).Key).To(Equal(2))
+		Eventually(done).Should(BeClosed())
+	})
+	It("should validate map and lazy streams", func() {
+		s := Of(map[string]validItem{"a": {Sku: "abc", Qty: 1}, "b": {Qty: 1}}).Validate()
+		Expect(s.Interface()).To(Equal(map[string]validItem{"a": {Sku: "abc", Qty: 1}}))
+		Expect(s.Violations()).To(Equal([]Violation{
+			{Key: "b", Field: "Sku", Rule: "required", Value: ""},
+			{Key: "b", Field: "Sku", Rule: "len=3", Value: ""},
+		}))
+
+		source := make(chan validItem, 3)
+		source <- validItem{Sku: "abc", Qty: 0}
+		source <- validItem{Sku: "abc", Qty: 1}
+		source <- validItem{Sku: "abc", Qty: 20}
+		close(source)
+		s = OfChan(source).Validate().Filter(func(content Content) bool { return true })
+		Expect(s.Interface()).To(Equal([]validItem{{Sku: "abc", Qty: 1}}))
+		Expect(s.Violations()).To(Equal([]Violation{
+			{Key: 0, Field: "Qty", Rule: "min=1", Value: 0},
+			{Key: 2, Field: "Qty", Rule: "max=10", Value: 20},
+		}))
+
+		s = Range(0, 50, 1).Map(func(content Content) Content {
+			return Content{Data: validItem{Sku: "abc", Qty: content.Data.(int)}}
+		}, []validItem{}).Validate(3)
+		Expect(s.Count()).To(Equal(10))
+		Expect(s.Violations()).To(HaveLen(40))
+	})
+	It("should pass elements without rules", func() {
+		s := Of([]int{1, 2}).Validate()
+		Expect(s.Interface()).To(Equal([]int{1, 2}))
+		Expect(s.Violations()).To(BeEmpty())
+	})
+	It("should set error for invalid tags", func() {
+		type wrong struct {
+			Name string `validate:"between=1"`
+		}
+		s := Of([]wrong{{Name: "a"}}).Validate()
+		Expect(s.Interface()).To(Equal([]wrong{}))
+		Expect(s.Err()).To(MatchError(ContainSubstring("unknown rule between")))
+
+		type wrongParam struct {
+			Ok bool `validate:"min=1"`
+		}
+		s = Of([]wrongParam{{}}).Validate()
+		Expect(s.Err()).To(HaveOccurred())
+	})
+})